@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/docker/libcontainer/cgroups"
 )
@@ -88,6 +89,30 @@ func Apply(c *cgroups.Cgroup, pid int) (map[string]string, error) {
 	return paths, nil
 }
 
+// GetPaths returns the paths of the container's cgroups in each of the mounted hierarchies
+// where they exist, without creating or joining them
+func GetPaths(c *cgroups.Cgroup) (map[string]string, error) {
+	d, err := getCgroupData(c, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	for name := range subsystems {
+		p, err := d.path(name)
+		if err != nil {
+			if cgroups.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		if cgroups.PathExists(p) {
+			paths[name] = p
+		}
+	}
+	return paths, nil
+}
+
 // Symmetrical public function to update device based cgroups.  Also available
 // in the systemd implementation.
 func ApplyDevices(c *cgroups.Cgroup, pid int) error {
@@ -131,6 +156,26 @@ func Freeze(c *cgroups.Cgroup, state cgroups.FreezerState) error {
 	return freezer.Set(d)
 }
 
+// GetFreezerState returns the current state of the container's freezer cgroup
+func GetFreezerState(c *cgroups.Cgroup) (cgroups.FreezerState, error) {
+	d, err := getCgroupData(c, 0)
+	if err != nil {
+		return cgroups.Undefined, err
+	}
+
+	dir, err := d.path("freezer")
+	if err != nil {
+		return cgroups.Undefined, err
+	}
+
+	state, err := readFile(dir, "freezer.state")
+	if err != nil {
+		return cgroups.Undefined, err
+	}
+
+	return cgroups.FreezerState(strings.TrimSpace(state)), nil
+}
+
 func GetPids(c *cgroups.Cgroup) ([]int, error) {
 	d, err := getCgroupData(c, 0)
 	if err != nil {
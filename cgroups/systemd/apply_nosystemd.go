@@ -27,3 +27,11 @@ func ApplyDevices(c *cgroups.Cgroup, pid int) error {
 func Freeze(c *cgroups.Cgroup, state cgroups.FreezerState) error {
 	return fmt.Errorf("Systemd not supported")
 }
+
+func GetFreezerState(c *cgroups.Cgroup) (cgroups.FreezerState, error) {
+	return cgroups.Undefined, fmt.Errorf("Systemd not supported")
+}
+
+func GetPaths(c *cgroups.Cgroup) (map[string]string, error) {
+	return nil, fmt.Errorf("Systemd not supported")
+}
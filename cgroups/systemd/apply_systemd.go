@@ -147,6 +147,11 @@ func Apply(c *cgroups.Cgroup, pid int) (map[string]string, error) {
 		return nil, err
 	}
 
+	return GetPaths(res.cgroup)
+}
+
+// GetPaths returns the paths of the container's cgroups in each of the mounted hierarchies
+func GetPaths(c *cgroups.Cgroup) (map[string]string, error) {
 	paths := make(map[string]string)
 	for _, sysname := range []string{
 		"devices",
@@ -158,7 +163,7 @@ func Apply(c *cgroups.Cgroup, pid int) (map[string]string, error) {
 		"perf_event",
 		"freezer",
 	} {
-		subsystemPath, err := getSubsystemPath(res.cgroup, sysname)
+		subsystemPath, err := getSubsystemPath(c, sysname)
 		if err != nil {
 			// Don't fail if a cgroup hierarchy was not found, just skip this subsystem
 			if cgroups.IsNotFound(err) {
@@ -229,6 +234,21 @@ func Freeze(c *cgroups.Cgroup, state cgroups.FreezerState) error {
 	return nil
 }
 
+// GetFreezerState returns the current state of the container's freezer cgroup
+func GetFreezerState(c *cgroups.Cgroup) (cgroups.FreezerState, error) {
+	path, err := getSubsystemPath(c, "freezer")
+	if err != nil {
+		return cgroups.Undefined, err
+	}
+
+	state, err := ioutil.ReadFile(filepath.Join(path, "freezer.state"))
+	if err != nil {
+		return cgroups.Undefined, err
+	}
+
+	return cgroups.FreezerState(bytes.TrimSpace(state)), nil
+}
+
 func GetPids(c *cgroups.Cgroup) ([]int, error) {
 	path, err := getSubsystemPath(c, "cpu")
 	if err != nil {
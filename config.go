@@ -19,6 +19,7 @@ const (
 	NEWUTS  NamespaceType = "NEWUTS"
 	NEWIPC  NamespaceType = "NEWIPC"
 	NEWUSER NamespaceType = "NEWUSER"
+	NEWTIME NamespaceType = "NEWTIME"
 )
 
 // Namespace defines configuration for each namespace.  It specifies an
@@ -69,9 +70,25 @@ type Config struct {
 	// Pathname to container's root filesystem
 	RootFs string `json:"root_fs,omitempty"`
 
-	// Hostname optionally sets the container's hostname if provided
+	// Hostname optionally sets the container's hostname if provided.  The container's
+	// /etc/hostname is generated for it as well
 	Hostname string `json:"hostname,omitempty"`
 
+	// Domainname optionally sets the container's NIS domain name if provided.  It requires the
+	// NEWUTS namespace like the hostname
+	Domainname string `json:"domainname,omitempty"`
+
+	// GenerateHosts replaces the rootfs's /etc/hosts with one generated from the localhost
+	// entries, the hostname and Hosts.  It is implied when Hosts are set
+	GenerateHosts bool `json:"generate_hosts,omitempty"`
+
+	// Hosts are additional entries for the container's generated /etc/hosts
+	Hosts []*HostEntry `json:"hosts,omitempty"`
+
+	// Dns generates the container's /etc/resolv.conf, the rootfs's resolv.conf is used if
+	// it is not set
+	Dns *Dns `json:"dns,omitempty"`
+
 	// User will set the uid and gid of the executing process running inside the container
 	User string `json:"user,omitempty"`
 
@@ -117,13 +134,55 @@ type Config struct {
 	// /proc/bus
 	RestrictSys bool `json:"restrict_sys,omitempty"`
 
+	// MaskedPaths are hidden from the container by bind mounting /dev/null over files and an empty
+	// readonly tmpfs over directories.  It defaults to /proc/kcore when RestrictSys is set
+	MaskedPaths []string `json:"masked_paths,omitempty"`
+
+	// ReadonlyPaths are remounted readonly inside the container.  It defaults to /proc/sys,
+	// /proc/sysrq-trigger, /proc/irq and /proc/bus when RestrictSys is set
+	ReadonlyPaths []string `json:"readonly_paths,omitempty"`
+
 	// Rlimits specifies the resource limits, such as max open files, to set in the container
 	// If Rlimits are not set, the container will inherit rlimits from the parent process
 	Rlimits []Rlimit `json:"rlimits,omitempty"`
 
+	// Subreaper marks the process starting the container as a child subreaper so that orphaned
+	// processes in the container are reparented to it and reaped instead of the host's init
+	Subreaper bool `json:"subreaper,omitempty"`
+
+	// BuiltinInit runs a minimal init as pid 1 in the container's pid namespace that forwards
+	// signals to the user's process and reaps orphaned processes.  It requires NEWPID
+	BuiltinInit bool `json:"builtin_init,omitempty"`
+
+	// OomScoreAdj adjusts the OOM killer score of the container's processes.  Values range
+	// from -1000, never kill, to 1000, always the preferred victim
+	OomScoreAdj *int `json:"oom_score_adj,omitempty"`
+
+	// Nice sets the nice value of the container's process
+	Nice *int `json:"nice,omitempty"`
+
+	// IOPriority sets the I/O scheduling class and priority of the container's process
+	IOPriority *IOPriority `json:"io_priority,omitempty"`
+
+	// Scheduler sets the CPU scheduling policy and priority of the container's process
+	Scheduler *Scheduler `json:"scheduler,omitempty"`
+
+	// CpuAffinity restricts the container's process to a list of CPUs, such as 0-3,8
+	CpuAffinity string `json:"cpu_affinity,omitempty"`
+
 	// AdditionalGroups specifies the gids that should be added to supplementary groups
 	// in addition to those that the user belongs to.
 	AdditionalGroups []int `json:"additional_groups,omitempty"`
+
+	// Sysctl is a map of kernel parameters, such as net.core.somaxconn, to their values that are
+	// written to /proc/sys inside the container.  Only parameters that are namespaced by one of
+	// the container's namespaces are allowed
+	Sysctl map[string]string `json:"sysctl,omitempty"`
+
+	// TimeOffsets specifies the offsets of the container's clocks from the host's clocks when
+	// the container is created with a new time namespace.  The key is the name of the clock,
+	// either "monotonic" or "boottime"
+	TimeOffsets map[string]TimeOffset `json:"time_offsets,omitempty"`
 }
 
 // Routes can be specified to create entries in the route table as the container is started
@@ -147,8 +206,49 @@ type Route struct {
 	InterfaceName string `json:"interface_name,omitempty"`
 }
 
+// TimeOffset is the offset of a clock inside the container's time namespace
+type TimeOffset struct {
+	Secs     int64  `json:"secs,omitempty"`
+	Nanosecs uint32 `json:"nanosecs,omitempty"`
+}
+
 type Rlimit struct {
-	Type int    `json:"type,omitempty"`
-	Hard uint64 `json:"hard,omitempty"`
-	Soft uint64 `json:"soft,omitempty"`
+	Type RlimitType `json:"type,omitempty"`
+	Hard uint64     `json:"hard,omitempty"`
+	Soft uint64     `json:"soft,omitempty"`
+}
+
+// IOPriority is the I/O scheduling class, one of IOPRIO_CLASS_RT, IOPRIO_CLASS_BE or
+// IOPRIO_CLASS_IDLE, and the priority within the class from 0, highest, to 7
+type IOPriority struct {
+	Class    string `json:"class,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// Scheduler is the CPU scheduling policy, one of SCHED_OTHER, SCHED_BATCH, SCHED_IDLE,
+// SCHED_FIFO or SCHED_RR, and the static priority used by the realtime policies
+type Scheduler struct {
+	Policy   string `json:"policy,omitempty"`
+	Priority int    `json:"priority,omitempty"`
+}
+
+// HostEntry is an entry in the container's /etc/hosts
+type HostEntry struct {
+	// Address is the IPv4 or IPv6 address of the host
+	Address string `json:"address,omitempty"`
+
+	// Hostnames are the names resolving to the address
+	Hostnames []string `json:"hostnames,omitempty"`
+}
+
+// Dns is the resolver configuration written to the container's /etc/resolv.conf
+type Dns struct {
+	// Servers are the addresses of the nameservers
+	Servers []string `json:"servers,omitempty"`
+
+	// Search are the domains searched for names that are not fully qualified
+	Search []string `json:"search,omitempty"`
+
+	// Options are resolver options such as ndots:2 or timeout:1
+	Options []string `json:"options,omitempty"`
 }
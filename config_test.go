@@ -170,3 +170,44 @@ func TestRemoveNamespace(t *testing.T) {
 		t.Fatalf("namespaces should have 0 items but reports %d", len(ns))
 	}
 }
+
+func TestRlimitTypeJson(t *testing.T) {
+	var rlimits []Rlimit
+	if err := json.Unmarshal([]byte(`[{"type": "RLIMIT_NOFILE", "hard": 1024}, {"type": 6, "hard": 512}]`), &rlimits); err != nil {
+		t.Fatal(err)
+	}
+	if rlimits[0].Type != 7 {
+		t.Fatalf("expected RLIMIT_NOFILE to be 7 but received %d", rlimits[0].Type)
+	}
+	if rlimits[1].Type != 6 {
+		t.Fatalf("expected raw rlimit type 6 but received %d", rlimits[1].Type)
+	}
+
+	data, err := json.Marshal(rlimits[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := `{"type":"RLIMIT_NPROC","hard":512}`; string(data) != expected {
+		t.Fatalf("expected %s but received %s", expected, data)
+	}
+
+	if err := json.Unmarshal([]byte(`{"type": "RLIMIT_UNKNOWN"}`), &Rlimit{}); err == nil {
+		t.Fatal("expected an error for an unknown rlimit type")
+	}
+}
+
+func TestProcessConfigApplyTo(t *testing.T) {
+	containerNice, processNice := 5, 19
+	container := &Config{Nice: &containerNice, CpuAffinity: "0-3"}
+
+	config := (&ProcessConfig{Nice: &processNice}).ApplyTo(container)
+	if *config.Nice != processNice {
+		t.Fatalf("expected the process nice value %d but received %d", processNice, *config.Nice)
+	}
+	if config.CpuAffinity != "0-3" {
+		t.Fatalf("expected the container's cpu affinity to be inherited but received %q", config.CpuAffinity)
+	}
+	if *container.Nice != containerNice {
+		t.Fatal("expected the container's config to be unchanged")
+	}
+}
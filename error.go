@@ -1,5 +1,10 @@
 package libcontainer
 
+import (
+	"fmt"
+	"runtime/debug"
+)
+
 // API error code type.
 type ErrorCode int
 
@@ -35,3 +40,34 @@ type Error interface {
 	// Returns the error code for this error.
 	Code() ErrorCode
 }
+
+// genericError is the default implementation of Error
+type genericError struct {
+	message string
+	code    ErrorCode
+	stack   []byte
+}
+
+func newGenericError(err error, code ErrorCode) Error {
+	return &genericError{
+		message: err.Error(),
+		code:    code,
+		stack:   debug.Stack(),
+	}
+}
+
+func (e *genericError) Error() string {
+	return e.message
+}
+
+func (e *genericError) Stack() []byte {
+	return e.stack
+}
+
+func (e *genericError) Detail() string {
+	return fmt.Sprintf("%s\n%s", e.message, e.stack)
+}
+
+func (e *genericError) Code() ErrorCode {
+	return e.code
+}
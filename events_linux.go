@@ -0,0 +1,264 @@
+// +build linux
+
+package libcontainer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// How often the container's state is checked for lifecycle transitions
+const eventPollInterval = 250 * time.Millisecond
+
+// The type of a container event.
+type EventType string
+
+const (
+	// The container's init process was started.
+	StartEvent EventType = "start"
+
+	// The container's processes were paused.
+	PauseEvent EventType = "pause"
+
+	// The container's processes were resumed after being paused.
+	ResumeEvent EventType = "resume"
+
+	// The container's init process exited.
+	ExitEvent EventType = "exit"
+
+	// A process in the container was killed by the OOM killer.
+	OOMEvent EventType = "oom"
+
+	// The container's memory usage crossed the configured threshold.
+	MemoryThresholdEvent EventType = "memory-threshold"
+
+	// Periodic statistics for the container.
+	StatsEvent EventType = "stats"
+)
+
+// Event is an event that occured for a container
+type Event struct {
+	Type EventType `json:"type"`
+	Time time.Time `json:"time"`
+
+	// ExitStatus is set for exit events when the container's exit status was recorded
+	ExitStatus *ExitStatus `json:"exit_status,omitempty"`
+
+	// MemoryUsage is set for memory threshold events to the usage in bytes
+	MemoryUsage uint64 `json:"memory_usage,omitempty"`
+
+	// Stats is set for stats events
+	Stats *ContainerStats `json:"stats,omitempty"`
+}
+
+// EventConfig selects the events that are sent in addition to the lifecycle and
+// OOM events that are always sent
+type EventConfig struct {
+	// StatsInterval is how often stats events are sent, no stats events are sent if it is 0
+	StatsInterval time.Duration
+
+	// MemoryThreshold sends a memory threshold event each time the container's memory
+	// usage rises above this number of bytes, no events are sent if it is 0.  It is not
+	// supported on cgroup v2.
+	MemoryThreshold uint64
+}
+
+// NotifyOnEvents returns a channel on which the events for the container with its state
+// in basePath are sent.  Lifecycle transitions are detected by watching the container's
+// state so an exit event is sent once the state is removed, and the channel is closed
+// after the exit event.  The events stop and the channel is closed once done is closed, a
+// caller that stops reading before the exit event must close done to release the watcher.
+func NotifyOnEvents(basePath string, container *Config, config *EventConfig, done <-chan struct{}) (<-chan *Event, error) {
+	state, err := GetState(basePath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	if config == nil {
+		config = &EventConfig{}
+	}
+
+	w := &eventWatcher{
+		basePath:  basePath,
+		container: container,
+		config:    config,
+		events:    make(chan *Event),
+		done:      done,
+		stop:      make(chan struct{}),
+	}
+
+	runState, err := GetRunState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if runState != Destroyed {
+		w.started(state)
+	}
+	w.runState = runState
+
+	go w.watch()
+
+	return w.events, nil
+}
+
+type eventWatcher struct {
+	basePath  string
+	container *Config
+	config    *EventConfig
+	events    chan *Event
+	done      <-chan struct{}
+
+	// stop is closed when the watcher returns to release the memory event registrations
+	stop chan struct{}
+
+	state      *State
+	runState   RunState
+	ooms       <-chan struct{}
+	thresholds <-chan struct{}
+	lastStats  time.Time
+}
+
+// send returns false if the event was not sent because the watcher is done
+func (w *eventWatcher) send(e *Event) bool {
+	e.Time = time.Now()
+	select {
+	case w.events <- e:
+		return true
+	case <-w.done:
+		return false
+	}
+}
+
+// started records the state of the running container and registers for its memory events
+func (w *eventWatcher) started(state *State) {
+	w.state = state
+
+	if state.CgroupPaths[oomCgroupName] == "" {
+		return
+	}
+
+	if ooms, err := notifyOnOOM(state, w.stop); err == nil {
+		w.ooms = ooms
+	}
+
+	if w.config.MemoryThreshold > 0 {
+		if thresholds, err := notifyOnMemoryThreshold(state, w.config.MemoryThreshold, w.stop); err == nil {
+			w.thresholds = thresholds
+		}
+	}
+}
+
+func (w *eventWatcher) watch() {
+	defer close(w.events)
+	defer close(w.stop)
+
+	ticker := time.NewTicker(eventPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case _, ok := <-w.ooms:
+			if !ok {
+				w.ooms = nil
+				continue
+			}
+			if !w.send(&Event{Type: OOMEvent}) {
+				return
+			}
+		case _, ok := <-w.thresholds:
+			if !ok {
+				w.thresholds = nil
+				continue
+			}
+			// the threshold is signaled when the usage crosses it in either direction
+			if usage, err := w.memoryUsage(); err == nil && usage >= w.config.MemoryThreshold {
+				if !w.send(&Event{Type: MemoryThresholdEvent, MemoryUsage: usage}) {
+					return
+				}
+			}
+		case <-ticker.C:
+			if stopped := w.poll(); stopped {
+				return
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+// poll sends the events for any changes to the container since the last poll and
+// returns true once the container has exited or the watcher is done
+func (w *eventWatcher) poll() bool {
+	state, err := GetState(w.basePath)
+	if err != nil && !os.IsNotExist(err) && !IsDestroyed(err) {
+		return false
+	}
+
+	runState, err := GetRunState(state)
+	if err != nil {
+		return false
+	}
+
+	switch {
+	case w.runState == Destroyed && runState != Destroyed:
+		w.started(state)
+		if !w.send(&Event{Type: StartEvent}) {
+			return true
+		}
+	case w.runState == Paused && runState == Running:
+		if !w.send(&Event{Type: ResumeEvent}) {
+			return true
+		}
+	case w.runState != Destroyed && runState == Destroyed:
+		// the exit status of a detached container is recorded after its state is removed
+		select {
+		case <-time.After(eventPollInterval):
+		case <-w.done:
+			return true
+		}
+
+		e := &Event{Type: ExitEvent}
+		if status, err := GetExitStatus(w.basePath); err == nil {
+			e.ExitStatus = status
+		}
+		w.send(e)
+
+		return true
+	}
+
+	if runState == Paused && w.runState != Paused {
+		if !w.send(&Event{Type: PauseEvent}) {
+			return true
+		}
+	}
+	w.runState = runState
+
+	if w.state == nil || runState == Destroyed {
+		return false
+	}
+
+	if w.config.StatsInterval > 0 && time.Since(w.lastStats) >= w.config.StatsInterval {
+		if stats, err := GetStats(w.container, w.state); err == nil {
+			if !w.send(&Event{Type: StatsEvent, Stats: stats}) {
+				return true
+			}
+		}
+		w.lastStats = time.Now()
+	}
+
+	return false
+}
+
+func (w *eventWatcher) memoryUsage() (uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(w.state.CgroupPaths[oomCgroupName], "memory.usage_in_bytes"))
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
@@ -0,0 +1,102 @@
+// +build linux
+
+package libcontainer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/docker/libcontainer/system"
+)
+
+func TestNotifyOnEventsStopsWhenDone(t *testing.T) {
+	root, err := ioutil.TempDir("", "libcontainer-events-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	memoryPath := filepath.Join(root, "memory")
+	eventPath := filepath.Join(memoryPath, "cgroup.event_control")
+	if err := os.Mkdir(memoryPath, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"memory.oom_control", "cgroup.event_control"} {
+		if err := ioutil.WriteFile(filepath.Join(memoryPath, name), []byte{}, 0700); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	started, err := system.GetProcessStartTime(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &State{
+		InitPid:       os.Getpid(),
+		InitStartTime: started,
+		CgroupPaths:   map[string]string{"memory": memoryPath},
+	}
+	if err := SaveState(root, state); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	events, err := NotifyOnEvents(root, &Config{}, nil, done)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := ioutil.ReadFile(eventPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var eventFd, oomControlFd int
+	if _, err := fmt.Sscanf(string(data), "%d %d", &eventFd, &oomControlFd); err != nil {
+		t.Fatalf("invalid control data %q: %s", data, err)
+	}
+
+	efd, err := syscall.Dup(eventFd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Close(efd)
+
+	// signal an oom that is never read so that the watcher blocks sending it
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, 1)
+	if _, err := syscall.Write(efd, buf); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(2 * eventPollInterval)
+
+	// the watcher returns without the oom being read
+	close(done)
+
+	for _, fd := range []int{eventFd, oomControlFd} {
+		closed := false
+		for i := 0; i < 100 && !closed; i++ {
+			_, _, err := syscall.Syscall(syscall.SYS_FCNTL, uintptr(fd), syscall.F_GETFD, 0)
+			if closed = err == syscall.EBADF; !closed {
+				time.Sleep(10 * time.Millisecond)
+			}
+		}
+		if !closed {
+			t.Fatalf("expected fd %d of the oom registration to be closed", fd)
+		}
+	}
+
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Fatal("expected no events after done is closed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the events channel to be closed after done is closed")
+	}
+}
@@ -0,0 +1,196 @@
+// +build linux
+
+package libcontainer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/libcontainer/cgroups"
+	"github.com/docker/libcontainer/cgroups/fs"
+	"github.com/docker/libcontainer/cgroups/systemd"
+	"github.com/docker/libcontainer/network"
+)
+
+// DestroyState removes the resources recorded in the state of a container whose init
+// process no longer exists: its cgroups, the host side of its veth pair and the
+// state.json file in the specified path.
+func DestroyState(basePath string, state *State) error {
+	for subsystem, path := range state.CgroupPaths {
+		pids, err := cgroups.ReadProcsFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return err
+		}
+
+		if len(pids) > 0 {
+			return fmt.Errorf("%s cgroup %s still has %d processes", subsystem, path, len(pids))
+		}
+	}
+
+	if err := cgroups.RemovePaths(state.CgroupPaths); err != nil {
+		return err
+	}
+
+	// the host side of the pair is deleted by the kernel along with the container's
+	// network namespace unless something else is holding the namespace open
+	if veth := state.NetworkState.VethHost; veth != "" {
+		if _, err := net.InterfaceByName(veth); err == nil {
+			if err := network.DeleteInterface(veth); err != nil {
+				return fmt.Errorf("delete veth %s %s", veth, err)
+			}
+		}
+	}
+
+	if err := DeleteState(basePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// GarbageCollect scans the state root for containers whose init process no longer
+// exists, for example because the process that started them was killed before it
+// could clean up, and destroys their state.  The cgroup hierarchies are also scanned
+// for the cgroups of containers in the state root that have no running init, which
+// are left behind when the process starting the container is killed before it saves
+// the state.  loadConfig returns the config of the container in a path of the state
+// root, and can be nil to only collect the containers with a state.  It returns the
+// paths of the containers that were collected.
+func GarbageCollect(root string, loadConfig func(basePath string) (*Config, error)) ([]string, error) {
+	entries, err := ioutil.ReadDir(root)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var (
+		collected []string
+		errs      []string
+	)
+
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(root, e.Name())
+
+		ok, err := collect(path, loadConfig)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", path, err))
+			continue
+		}
+
+		if ok {
+			collected = append(collected, path)
+		}
+	}
+
+	if len(errs) > 0 {
+		return collected, fmt.Errorf("failed to collect containers: %s", strings.Join(errs, ", "))
+	}
+
+	return collected, nil
+}
+
+// collect destroys the state and cgroups in basePath if its container no longer exists.
+// Containers are locked before they are started so a path without a lock file never had a
+// container to collect, and the lock file is not created for it.
+func collect(basePath string, loadConfig func(basePath string) (*Config, error)) (bool, error) {
+	lock, err := lockExistingState(basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	defer lock.Unlock()
+
+	state, err := GetState(basePath)
+	if err == nil {
+		return false, nil
+	}
+
+	collected := false
+	switch {
+	case IsDestroyed(err):
+		if err := DestroyState(basePath, state); err != nil {
+			return false, err
+		}
+		collected = true
+	case !os.IsNotExist(err):
+		return false, err
+	}
+
+	if loadConfig == nil {
+		return collected, nil
+	}
+
+	container, err := loadConfig(basePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return collected, nil
+		}
+		return collected, err
+	}
+
+	removed, err := collectCgroups(container)
+	if err != nil {
+		return collected, err
+	}
+
+	return collected || removed, nil
+}
+
+// collectCgroups removes the cgroups of a container without a running init.  The cgroups
+// are only removed if none of them have any processes left.
+func collectCgroups(container *Config) (bool, error) {
+	if container.Cgroups == nil {
+		return false, nil
+	}
+
+	getPaths := fs.GetPaths
+	if systemd.UseSystemd() {
+		getPaths = systemd.GetPaths
+	}
+
+	paths, err := getPaths(container.Cgroups)
+	if err != nil {
+		return false, err
+	}
+
+	for subsystem, path := range paths {
+		pids, err := cgroups.ReadProcsFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				delete(paths, subsystem)
+				continue
+			}
+			return false, err
+		}
+
+		// the cgroup is in use by processes that were started outside of the state root
+		if len(pids) > 0 {
+			return false, nil
+		}
+	}
+
+	if len(paths) == 0 {
+		return false, nil
+	}
+
+	if err := cgroups.RemovePaths(paths); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,69 @@
+// +build linux
+
+package libcontainer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/libcontainer/system"
+)
+
+func TestGarbageCollect(t *testing.T) {
+	root, err := ioutil.TempDir("", "libcontainer-gc-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	started, err := system.GetProcessStartTime(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states := map[string]*State{
+		"running": {InitPid: os.Getpid(), InitStartTime: started},
+		"stale":   {InitPid: os.Getpid(), InitStartTime: "1"},
+	}
+	for name, state := range states {
+		path := filepath.Join(root, name)
+		if err := os.Mkdir(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		lock, err := LockState(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = SaveState(path, state)
+		lock.Unlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// a directory that never had a container started in it
+	unused := filepath.Join(root, "unused")
+	if err := os.Mkdir(unused, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	collected, err := GarbageCollect(root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(collected) != 1 || collected[0] != filepath.Join(root, "stale") {
+		t.Fatalf("expected only the stale container to be collected, got %v", collected)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "stale", stateFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected the stale state to be removed, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "running", stateFile)); err != nil {
+		t.Fatalf("expected the running state to be kept: %s", err)
+	}
+	if _, err := os.Stat(filepath.Join(unused, lockFile)); !os.IsNotExist(err) {
+		t.Fatalf("expected no lock file to be created in %s, got %v", unused, err)
+	}
+}
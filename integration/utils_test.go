@@ -60,7 +60,8 @@ func newRootFs() (string, error) {
 		return "", err
 	}
 	if err := copyBusybox(dir); err != nil {
-		return "", nil
+		os.RemoveAll(dir)
+		return "", err
 	}
 	return dir, nil
 }
@@ -8,38 +8,45 @@ import (
 	"path/filepath"
 	"syscall"
 
-	"github.com/docker/libcontainer/label"
 	"github.com/docker/libcontainer/mount/nodes"
+	"github.com/docker/libcontainer/system"
 )
 
 // default mount point flags
 const defaultMountFlags = syscall.MS_NOEXEC | syscall.MS_NOSUID | syscall.MS_NODEV
 
-type mount struct {
-	source string
-	path   string
-	device string
-	flags  int
-	data   string
-}
-
 // InitializeMountNamespace sets up the devices, mount points, and filesystems for use inside a
 // new mount namespace.
 func InitializeMountNamespace(rootfs, console string, sysReadonly bool, mountConfig *MountConfig) error {
 	var (
-		err  error
-		flag = syscall.MS_PRIVATE
+		err         error
+		propagation = mountConfig.RootPropagation
 	)
 
-	if mountConfig.NoPivotRoot {
-		flag = syscall.MS_SLAVE
+	if propagation == "" {
+		propagation = "rprivate"
+		if mountConfig.NoPivotRoot {
+			propagation = "rslave"
+		}
 	}
 
-	if err := syscall.Mount("", "/", "", uintptr(flag|syscall.MS_REC), ""); err != nil {
-		return fmt.Errorf("mounting / with flags %X %s", (flag | syscall.MS_REC), err)
+	if err := setPropagation("/", propagation); err != nil {
+		return err
 	}
 
-	if err := syscall.Mount(rootfs, rootfs, "bind", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
+	// pivot_root fails when the rootfs is bind mounted from a shared mount so the mount
+	// containing the rootfs is made private, leaving the rest of / shared
+	if propagationFlags[propagation]&syscall.MS_SHARED != 0 && !mountConfig.NoPivotRoot {
+		if err := rootfsParentMountPrivate(rootfs); err != nil {
+			return err
+		}
+	}
+
+	if mountConfig.RootfsLayers != nil {
+		if err := mountRootfsLayers(rootfs, mountConfig.RootfsLayers, mountConfig.MountLabel); err != nil {
+			return fmt.Errorf("mounting rootfs layers %s", err)
+		}
+	} else if err := syscall.Mount(rootfs, rootfs, "bind", syscall.MS_BIND|syscall.MS_REC, ""); err != nil {
 		return fmt.Errorf("mouting %s as bind %s", rootfs, err)
 	}
 
@@ -97,40 +104,80 @@ func InitializeMountNamespace(rootfs, console string, sysReadonly bool, mountCon
 	return nil
 }
 
+// rootfsParentMountPrivate makes the mount containing the rootfs private
+func rootfsParentMountPrivate(rootfs string) error {
+	path, err := filepath.EvalSymlinks(rootfs)
+	if err != nil {
+		return err
+	}
+
+	mountpoint, err := findMountPoint(path)
+	if err != nil {
+		return err
+	}
+
+	return setPropagation(mountpoint, "private")
+}
+
 // mountSystem sets up linux specific system mounts like mqueue, sys, proc, shm, and devpts
 // inside the mount namespace
 func mountSystem(rootfs string, sysReadonly bool, mountConfig *MountConfig) error {
-	for _, m := range newSystemMounts(rootfs, mountConfig.MountLabel, sysReadonly) {
-		if err := os.MkdirAll(m.path, 0755); err != nil && !os.IsExist(err) {
-			return fmt.Errorf("mkdirall %s %s", m.path, err)
+	systemMounts := mountConfig.SystemMounts
+	if systemMounts == nil {
+		systemMounts = DefaultSystemMounts()
+	}
+
+	for _, m := range systemMounts {
+		var (
+			source                   = m.Source
+			path                     = filepath.Join(rootfs, m.Destination)
+			flags, propagation, data = parseOptions(m.Options, m.Data)
+		)
+
+		if source == "" {
+			source = m.Type
+		}
+
+		if m.Type == "sysfs" && sysReadonly {
+			flags |= syscall.MS_RDONLY
+		}
+
+		data = formatMountLabel(m.Type, data, mountConfig.MountLabel)
+
+		// the system mounts are configurable so their targets are resolved inside the
+		// rootfs the same as the container's other mounts
+		if err := system.CreateInRoot(rootfs, m.Destination, true); err != nil {
+			return fmt.Errorf("creating system mount target %s", err)
+		}
+		if err := system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+			return syscall.Mount(source, procfd, m.Type, uintptr(flags), data)
+		}); err != nil {
+			return fmt.Errorf("mounting %s into %s %s", source, path, err)
+		}
+
+		if propagation == "" {
+			continue
 		}
-		if err := syscall.Mount(m.source, m.path, m.device, uintptr(m.flags), m.data); err != nil {
-			return fmt.Errorf("mounting %s into %s %s", m.source, m.path, err)
+		if err := system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+			return setPropagation(procfd, propagation)
+		}); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func createIfNotExists(path string, isDir bool) error {
-	if _, err := os.Stat(path); err != nil {
-		if os.IsNotExist(err) {
-			if isDir {
-				if err := os.MkdirAll(path, 0755); err != nil {
-					return err
-				}
-			} else {
-				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
-					return err
-				}
-				f, err := os.OpenFile(path, os.O_CREATE, 0755)
-				if err != nil {
-					return err
-				}
-				f.Close()
-			}
-		}
+// DefaultSystemMounts returns the system mounts used when a container does not configure
+// its own.  /sys is mounted readonly as well when the container restricts /sys.
+func DefaultSystemMounts() []*Mount {
+	return []*Mount{
+		{Type: "proc", Source: "proc", Destination: "/proc", Options: []string{"noexec", "nosuid", "nodev"}},
+		{Type: "tmpfs", Source: "tmpfs", Destination: "/dev", Options: []string{"nosuid", "strictatime", "mode=755"}},
+		{Type: "tmpfs", Source: "shm", Destination: "/dev/shm", Options: []string{"noexec", "nosuid", "nodev", "mode=1777", "size=65536k"}},
+		{Type: "mqueue", Source: "mqueue", Destination: "/dev/mqueue", Options: []string{"noexec", "nosuid", "nodev"}},
+		{Type: "devpts", Source: "devpts", Destination: "/dev/pts", Options: []string{"nosuid", "noexec", "newinstance", "ptmxmode=0666", "mode=620", "gid=5"}},
+		{Type: "sysfs", Source: "sysfs", Destination: "/sys", Options: []string{"noexec", "nosuid", "nodev"}},
 	}
-	return nil
 }
 
 func setupDevSymlinks(rootfs string) error {
@@ -161,27 +208,6 @@ func setupDevSymlinks(rootfs string) error {
 	return nil
 }
 
-// TODO: this is crappy right now and should be cleaned up with a better way of handling system and
-// standard bind mounts allowing them to be more dynamic
-func newSystemMounts(rootfs, mountLabel string, sysReadonly bool) []mount {
-	systemMounts := []mount{
-		{source: "proc", path: filepath.Join(rootfs, "proc"), device: "proc", flags: defaultMountFlags},
-		{source: "tmpfs", path: filepath.Join(rootfs, "dev"), device: "tmpfs", flags: syscall.MS_NOSUID | syscall.MS_STRICTATIME, data: label.FormatMountLabel("mode=755", mountLabel)},
-		{source: "shm", path: filepath.Join(rootfs, "dev", "shm"), device: "tmpfs", flags: defaultMountFlags, data: label.FormatMountLabel("mode=1777,size=65536k", mountLabel)},
-		{source: "mqueue", path: filepath.Join(rootfs, "dev", "mqueue"), device: "mqueue", flags: defaultMountFlags},
-		{source: "devpts", path: filepath.Join(rootfs, "dev", "pts"), device: "devpts", flags: syscall.MS_NOSUID | syscall.MS_NOEXEC, data: label.FormatMountLabel("newinstance,ptmxmode=0666,mode=620,gid=5", mountLabel)},
-	}
-
-	sysMountFlags := defaultMountFlags
-	if sysReadonly {
-		sysMountFlags |= syscall.MS_RDONLY
-	}
-
-	systemMounts = append(systemMounts, mount{source: "sysfs", path: filepath.Join(rootfs, "sys"), device: "sysfs", flags: sysMountFlags})
-
-	return systemMounts
-}
-
 // Is stdin, stdout or stderr were to be pointing to '/dev/null',
 // this method will make them point to '/dev/null' from within this namespace.
 func reOpenDevNull(rootfs string) error {
@@ -0,0 +1,36 @@
+// +build linux
+
+package mount
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestDefaultSystemMounts(t *testing.T) {
+	expected := []struct {
+		destination string
+		flags       int
+		data        string
+	}{
+		{"/proc", defaultMountFlags, ""},
+		{"/dev", syscall.MS_NOSUID | syscall.MS_STRICTATIME, "mode=755"},
+		{"/dev/shm", defaultMountFlags, "mode=1777,size=65536k"},
+		{"/dev/mqueue", defaultMountFlags, ""},
+		{"/dev/pts", syscall.MS_NOSUID | syscall.MS_NOEXEC, "newinstance,ptmxmode=0666,mode=620,gid=5"},
+		{"/sys", defaultMountFlags, ""},
+	}
+
+	mounts := DefaultSystemMounts()
+	if len(mounts) != len(expected) {
+		t.Fatalf("expected %d system mounts, got %d", len(expected), len(mounts))
+	}
+
+	for i, e := range expected {
+		m := mounts[i]
+		flags, _, data := parseOptions(m.Options, m.Data)
+		if m.Destination != e.destination || flags != e.flags || data != e.data {
+			t.Errorf("expected %s with flags %X and data %q, got %s with flags %X and data %q", e.destination, e.flags, e.data, m.Destination, flags, data)
+		}
+	}
+}
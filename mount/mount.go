@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"syscall"
 
-	"github.com/docker/docker/pkg/symlink"
+	"github.com/docker/libcontainer/cgroups"
 	"github.com/docker/libcontainer/label"
+	"github.com/docker/libcontainer/system"
 )
 
 type Mount struct {
@@ -18,6 +20,17 @@ type Mount struct {
 	Relabel     string `json:"relabel,omitempty"` // Relabel source if set, "z" indicates shared, "Z" indicates unshared
 	Private     bool   `json:"private,omitempty"`
 	Slave       bool   `json:"slave,omitempty"`
+
+	// Propagation is the propagation mode of the mount, one of private, rprivate, slave,
+	// rslave, shared, rshared, unbindable or runbindable.  It overrides Private and Slave.
+	Propagation string `json:"propagation,omitempty"`
+
+	// Data is passed to the filesystem along with any options that are not mount flags
+	Data string `json:"data,omitempty"`
+
+	// Options are mount(8) style options such as nosuid, nodev, ro, size=65536k or mode=755.
+	// A propagation mode such as rshared is used when Propagation is not set.
+	Options []string `json:"options,omitempty"`
 }
 
 func (m *Mount) Mount(rootfs, mountLabel string) error {
@@ -26,8 +39,12 @@ func (m *Mount) Mount(rootfs, mountLabel string) error {
 		return m.bindMount(rootfs, mountLabel)
 	case "tmpfs":
 		return m.tmpfsMount(rootfs, mountLabel)
+	case "cgroup":
+		return m.cgroupMount(rootfs, mountLabel)
+	case "":
+		return fmt.Errorf("no mount type specified for %s", m.Destination)
 	default:
-		return fmt.Errorf("unsupported mount type %s for %s", m.Type, m.Destination)
+		return m.genericMount(rootfs, mountLabel)
 	}
 }
 
@@ -41,69 +58,217 @@ func (m *Mount) bindMount(rootfs, mountLabel string) error {
 		flags = flags | syscall.MS_RDONLY
 	}
 
-	if m.Slave {
-		flags = flags | syscall.MS_SLAVE
-	}
+	// options such as nosuid or noexec only apply to a bind mount when it is remounted
+	extra, _, _ := parseOptions(m.Options, "")
+	flags = flags | extra&^(syscall.MS_BIND|syscall.MS_REC|syscall.MS_REMOUNT)
 
 	stat, err := os.Stat(m.Source)
 	if err != nil {
 		return err
 	}
 
-	// FIXME: (crosbymichael) This does not belong here and should be done a layer above
-	dest, err = symlink.FollowSymlinkInScope(dest, rootfs)
-	if err != nil {
-		return err
-	}
-
-	if err := createIfNotExists(dest, stat.IsDir()); err != nil {
+	if err := system.CreateInRoot(rootfs, m.Destination, stat.IsDir()); err != nil {
 		return fmt.Errorf("creating new bind mount target %s", err)
 	}
 
-	if err := syscall.Mount(m.Source, dest, "bind", uintptr(flags), ""); err != nil {
+	if err := system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+		return syscall.Mount(m.Source, procfd, "bind", uintptr(flags), "")
+	}); err != nil {
 		return fmt.Errorf("mounting %s into %s %s", m.Source, dest, err)
 	}
 
-	if !m.Writable {
-		if err := syscall.Mount(m.Source, dest, "bind", uintptr(flags|syscall.MS_REMOUNT), ""); err != nil {
+	// the target is opened again for each change so that it refers to the new mount
+	if flags&^(syscall.MS_BIND|syscall.MS_REC) != 0 {
+		if err := system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+			return syscall.Mount(m.Source, procfd, "bind", uintptr(flags|syscall.MS_REMOUNT), "")
+		}); err != nil {
 			return fmt.Errorf("remounting %s into %s %s", m.Source, dest, err)
 		}
 	}
 
+	// the remount only made the top level mount readonly, not the submounts of the source
+	if !m.Writable {
+		if err := system.WithProcfd(rootfs, m.Destination, remountReadonlyRecursive); err != nil {
+			return err
+		}
+	}
+
 	if m.Relabel != "" {
 		if err := label.Relabel(m.Source, mountLabel, m.Relabel); err != nil {
 			return fmt.Errorf("relabeling %s to %s %s", m.Source, mountLabel, err)
 		}
 	}
 
-	if m.Private {
-		if err := syscall.Mount("", dest, "none", uintptr(syscall.MS_PRIVATE), ""); err != nil {
-			return fmt.Errorf("mounting %s private %s", dest, err)
-		}
+	return m.setPropagation(rootfs)
+}
+
+// setPropagation applies the mount's propagation mode after it has been mounted
+func (m *Mount) setPropagation(rootfs string) error {
+	propagation := m.propagation()
+	if propagation == "" {
+		return nil
 	}
 
-	return nil
+	return system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+		return setPropagation(procfd, propagation)
+	})
+}
+
+// propagation returns the propagation mode of the mount, falling back to a propagation
+// mode in its options and then the Private and Slave fields
+func (m *Mount) propagation() string {
+	if m.Propagation != "" {
+		return m.Propagation
+	}
+
+	if _, propagation, _ := parseOptions(m.Options, ""); propagation != "" {
+		return propagation
+	}
+
+	switch {
+	case m.Private:
+		return "private"
+	case m.Slave:
+		return "slave"
+	}
+	return ""
 }
 
 func (m *Mount) tmpfsMount(rootfs, mountLabel string) error {
+	var (
+		flags = defaultMountFlags
+		data  = m.Data
+		dest  = filepath.Join(rootfs, m.Destination)
+	)
+
+	if len(m.Options) > 0 {
+		flags, _, data = parseOptions(m.Options, m.Data)
+	}
+
+	if err := system.CreateInRoot(rootfs, m.Destination, true); err != nil {
+		return fmt.Errorf("creating new tmpfs mount target %s", err)
+	}
+
+	if err := system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+		return syscall.Mount("tmpfs", procfd, "tmpfs", uintptr(flags), label.FormatMountLabel(data, mountLabel))
+	}); err != nil {
+		return fmt.Errorf("%s mounting %s in tmpfs", err, dest)
+	}
+
+	return m.setPropagation(rootfs)
+}
+
+// genericMount mounts a filesystem of the mount's type using the flags and data from
+// its options.  The source defaults to the type for pseudo filesystems such as proc.
+func (m *Mount) genericMount(rootfs, mountLabel string) error {
+	var (
+		source         = m.Source
+		flags, _, data = parseOptions(m.Options, m.Data)
+		dest           = filepath.Join(rootfs, m.Destination)
+	)
+
+	if source == "" {
+		source = m.Type
+	}
+
+	data = formatMountLabel(m.Type, data, mountLabel)
+
+	if err := system.CreateInRoot(rootfs, m.Destination, true); err != nil {
+		return fmt.Errorf("creating new %s mount target %s", m.Type, err)
+	}
+
+	if err := system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+		return syscall.Mount(source, procfd, m.Type, uintptr(flags), data)
+	}); err != nil {
+		return fmt.Errorf("mounting %s into %s as %s %s", source, dest, m.Type, err)
+	}
+
+	return m.setPropagation(rootfs)
+}
+
+// cgroupMount creates a tmpfs at the mount's destination and bind mounts the container's
+// own cgroup directory for each mounted hierarchy underneath it.  Co-mounted subsystems
+// such as cpu,cpuacct are mounted once with a symlink created for each subsystem name.
+// The hierarchies are mounted readonly unless the mount is marked as writable.
+func (m *Mount) cgroupMount(rootfs, mountLabel string) error {
 	var (
 		err  error
-		l    = label.FormatMountLabel("", mountLabel)
 		dest = filepath.Join(rootfs, m.Destination)
 	)
 
-	// FIXME: (crosbymichael) This does not belong here and should be done a layer above
-	if dest, err = symlink.FollowSymlinkInScope(dest, rootfs); err != nil {
+	if err := system.CreateInRoot(rootfs, m.Destination, true); err != nil {
+		return fmt.Errorf("creating new cgroup mount target %s", err)
+	}
+
+	if err := system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+		return syscall.Mount("tmpfs", procfd, "tmpfs", uintptr(defaultMountFlags), label.FormatMountLabel("mode=755", mountLabel))
+	}); err != nil {
+		return fmt.Errorf("%s mounting %s in tmpfs", err, dest)
+	}
+
+	cgroupMounts, err := cgroups.GetCgroupMounts()
+	if err != nil {
 		return err
 	}
 
-	if err := createIfNotExists(dest, true); err != nil {
-		return fmt.Errorf("creating new tmpfs mount target %s", err)
+	for _, cm := range cgroupMounts {
+		if len(cm.Subsystems) == 0 {
+			continue
+		}
+
+		// the init process has already been placed into the container's cgroups so the
+		// current cgroup of this process is the container's cgroup
+		dir, err := cm.GetThisCgroupDir()
+		if err != nil {
+			if cgroups.IsNotFound(err) {
+				continue
+			}
+			return err
+		}
+
+		var (
+			name   = filepath.Base(cm.Mountpoint)
+			source = filepath.Join(cm.Mountpoint, dir)
+			bind   = &Mount{
+				Type:        "bind",
+				Source:      source,
+				Destination: filepath.Join(m.Destination, name),
+				Writable:    m.Writable,
+			}
+		)
+
+		if err := bind.bindMount(rootfs, mountLabel); err != nil {
+			return err
+		}
+
+		if err := m.cgroupSymlinks(rootfs, name); err != nil {
+			return err
+		}
 	}
 
-	if err := syscall.Mount("tmpfs", dest, "tmpfs", uintptr(defaultMountFlags), l); err != nil {
-		return fmt.Errorf("%s mounting %s in tmpfs", err, dest)
+	if !m.Writable {
+		if err := system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+			return syscall.Mount("tmpfs", procfd, "tmpfs", uintptr(defaultMountFlags|syscall.MS_REMOUNT|syscall.MS_RDONLY), "")
+		}); err != nil {
+			return fmt.Errorf("remounting %s readonly %s", dest, err)
+		}
 	}
 
 	return nil
 }
+
+// cgroupSymlinks creates a symlink to the directory of a co-mounted hierarchy such as
+// cpu,cpuacct for each of its subsystems
+func (m *Mount) cgroupSymlinks(rootfs, name string) error {
+	for _, subsystem := range strings.Split(name, ",") {
+		if subsystem == name {
+			continue
+		}
+		if err := system.WithProcfd(rootfs, m.Destination, func(procfd string) error {
+			return os.Symlink(name, filepath.Join(procfd, subsystem))
+		}); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("symlink %s %s %s", name, subsystem, err)
+		}
+	}
+	return nil
+}
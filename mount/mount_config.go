@@ -13,10 +13,20 @@ type MountConfig struct {
 	// This is a common option when the container is running in ramdisk
 	NoPivotRoot bool `json:"no_pivot_root,omitempty"`
 
+	// RootPropagation is the propagation mode applied recursively to all mounts in the new
+	// mount namespace, one of rprivate, rslave or rshared.  Mounts are propagated back to the
+	// host with rshared.  The default is rprivate, or rslave with NoPivotRoot
+	RootPropagation string `json:"root_propagation,omitempty"`
+
 	// ReadonlyFs will remount the container's rootfs as readonly where only externally mounted
 	// bind mounts are writtable
 	ReadonlyFs bool `json:"readonly_fs,omitempty"`
 
+	// SystemMounts are the filesystems such as /proc, /dev and /sys that are mounted into the
+	// rootfs before any other mounts.  The mounts from DefaultSystemMounts are used if it is
+	// not set, an empty list mounts no system filesystems
+	SystemMounts []*Mount `json:"system_mounts"`
+
 	// Mounts specify additional source and destination paths that will be mounted inside the container's
 	// rootfs and mount namespace if specified
 	Mounts []*Mount `json:"mounts,omitempty"`
@@ -25,4 +35,23 @@ type MountConfig struct {
 	DeviceNodes []*devices.Device `json:"device_nodes,omitempty"`
 
 	MountLabel string `json:"mount_label,omitempty"`
+
+	// RootfsLayers assembles the container's rootfs from layered directories with an overlay
+	// mount on top of the rootfs path instead of using the rootfs directory as it is
+	RootfsLayers *RootfsLayers `json:"rootfs_layers,omitempty"`
+}
+
+// RootfsLayers are the directories stacked with overlayfs to create the container's rootfs.
+// The upper directory is left in place after the container exits so that the changes made
+// by the container can be inspected or committed.
+type RootfsLayers struct {
+	// Lower are the readonly layers with the top most layer first
+	Lower []string `json:"lower,omitempty"`
+
+	// Upper is the writable layer that receives the container's changes.  If it is not set
+	// the rootfs is readonly and at least two lower layers are required
+	Upper string `json:"upper,omitempty"`
+
+	// Work is an empty directory on the same filesystem as the upper layer used by overlayfs
+	Work string `json:"work,omitempty"`
 }
@@ -0,0 +1,102 @@
+// +build linux
+
+package mount
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/docker/libcontainer/cgroups"
+)
+
+func TestCgroupSymlinks(t *testing.T) {
+	rootfs, err := ioutil.TempDir("", "libcontainer-cgroup-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootfs)
+
+	m := &Mount{Type: "cgroup", Destination: "/sys/fs/cgroup"}
+	if err := os.MkdirAll(filepath.Join(rootfs, m.Destination, "cpu,cpuacct"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"cpu,cpuacct", "memory"} {
+		if err := m.cgroupSymlinks(rootfs, name); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, subsystem := range []string{"cpu", "cpuacct"} {
+		target, err := os.Readlink(filepath.Join(rootfs, m.Destination, subsystem))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if target != "cpu,cpuacct" {
+			t.Fatalf("expected %s to link to cpu,cpuacct but it links to %s", subsystem, target)
+		}
+	}
+
+	// a hierarchy with a single subsystem is mounted under its own name without a symlink
+	if _, err := os.Lstat(filepath.Join(rootfs, m.Destination, "memory")); !os.IsNotExist(err) {
+		t.Fatalf("expected no symlink for memory, got %v", err)
+	}
+
+	// the symlinks already existing is not an error
+	if err := m.cgroupSymlinks(rootfs, "cpu,cpuacct"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCgroupMountReadonly(t *testing.T) {
+	if testing.Short() || os.Getuid() != 0 {
+		t.Skip("requires root to create mounts")
+	}
+
+	rootfs, err := ioutil.TempDir("", "libcontainer-cgroup-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootfs)
+
+	m := &Mount{Type: "cgroup", Destination: "/sys/fs/cgroup"}
+	if err := m.Mount(rootfs, ""); err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(rootfs, m.Destination)
+	defer syscall.Unmount(dest, syscall.MNT_DETACH)
+
+	cgroupMounts, err := cgroups.GetCgroupMounts()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cm := range cgroupMounts {
+		if len(cm.Subsystems) == 0 {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dest, filepath.Base(cm.Mountpoint))); err != nil {
+			t.Fatalf("expected the %s hierarchy to be mounted: %s", cm.Mountpoint, err)
+		}
+	}
+
+	// both the tmpfs and the hierarchies are readonly by default
+	paths := []string{dest}
+	if len(cgroupMounts) > 0 {
+		paths = append(paths, filepath.Join(dest, filepath.Base(cgroupMounts[0].Mountpoint)))
+	}
+	for _, p := range paths {
+		err := os.Mkdir(filepath.Join(p, "libcontainer-test"), 0755)
+		if err == nil {
+			os.Remove(filepath.Join(p, "libcontainer-test"))
+			t.Fatalf("expected %s to be readonly", p)
+		}
+		if !strings.Contains(err.Error(), syscall.EROFS.Error()) {
+			t.Fatalf("expected a readonly filesystem error for %s, got %s", p, err)
+		}
+	}
+}
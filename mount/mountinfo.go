@@ -0,0 +1,84 @@
+// +build linux
+
+package mount
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// mountInfo is an entry in /proc/self/mountinfo
+type mountInfo struct {
+	id         int
+	parent     int
+	mountpoint string
+	options    string
+}
+
+// getMountInfo returns the mounts of the current process's mount namespace
+func getMountInfo() ([]*mountInfo, error) {
+	f, err := os.Open("/proc/self/mountinfo")
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseMountInfo(f)
+}
+
+func parseMountInfo(r io.Reader) ([]*mountInfo, error) {
+	var (
+		s      = bufio.NewScanner(r)
+		mounts = []*mountInfo{}
+	)
+
+	for s.Scan() {
+		// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+		fields := strings.Fields(s.Text())
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("invalid mountinfo line %q", s.Text())
+		}
+
+		m := &mountInfo{
+			mountpoint: unescapeMountPath(fields[4]),
+			options:    fields[5],
+		}
+		if _, err := fmt.Sscanf(fields[0]+" "+fields[1], "%d %d", &m.id, &m.parent); err != nil {
+			return nil, fmt.Errorf("invalid mountinfo line %q %s", s.Text(), err)
+		}
+
+		mounts = append(mounts, m)
+	}
+
+	return mounts, s.Err()
+}
+
+// unescapeMountPath replaces the octal escapes used for spaces, tabs, newlines and
+// backslashes in mount paths
+func unescapeMountPath(path string) string {
+	return strings.NewReplacer(`\040`, " ", `\011`, "\t", `\012`, "\n", `\134`, `\`).Replace(path)
+}
+
+// findMountPoint returns the mountpoint of the mount that contains path
+func findMountPoint(path string) (string, error) {
+	mounts, err := getMountInfo()
+	if err != nil {
+		return "", err
+	}
+
+	var mountpoint string
+	for _, m := range mounts {
+		if (path == m.mountpoint || m.mountpoint == "/" || strings.HasPrefix(path, m.mountpoint+"/")) && len(m.mountpoint) > len(mountpoint) {
+			mountpoint = m.mountpoint
+		}
+	}
+
+	if mountpoint == "" {
+		return "", fmt.Errorf("no mount found for %s", path)
+	}
+
+	return mountpoint, nil
+}
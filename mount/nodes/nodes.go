@@ -4,11 +4,11 @@ package nodes
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"syscall"
 
 	"github.com/docker/libcontainer/devices"
+	"github.com/docker/libcontainer/system"
 )
 
 // Create the device nodes in the container.
@@ -24,17 +24,12 @@ func CreateDeviceNodes(rootfs string, nodesToCreate []*devices.Device) error {
 	return nil
 }
 
-// Creates the device node in the rootfs of the container.
-func CreateDeviceNode(rootfs string, node *devices.Device) error {
-	var (
-		dest   = filepath.Join(rootfs, node.Path)
-		parent = filepath.Dir(dest)
-	)
-
-	if err := os.MkdirAll(parent, 0755); err != nil {
-		return err
-	}
+// mknodat is replaced in tests to exercise the bind mount fallback
+var mknodat = syscall.Mknodat
 
+// Creates the device node in the rootfs of the container.  The node's path is resolved
+// inside the rootfs so that a symlink in the rootfs can not redirect it onto the host.
+func CreateDeviceNode(rootfs string, node *devices.Device) error {
 	fileMode := node.FileMode
 	switch node.Type {
 	case 'c':
@@ -45,13 +40,69 @@ func CreateDeviceNode(rootfs string, node *devices.Device) error {
 		return fmt.Errorf("%c is not a valid device type for device %s", node.Type, node.Path)
 	}
 
-	if err := syscall.Mknod(dest, uint32(fileMode), devices.Mkdev(node.MajorNumber, node.MinorNumber)); err != nil && !os.IsExist(err) {
-		return fmt.Errorf("mknod %s %s", node.Path, err)
+	parent, name := filepath.Split(filepath.Clean(node.Path))
+	if err := system.CreateInRoot(rootfs, parent, true); err != nil {
+		return err
+	}
+
+	dir, err := system.OpenInRoot(rootfs, parent)
+	if err != nil {
+		return err
 	}
+	defer dir.Close()
+
+	if err := mknodat(int(dir.Fd()), name, uint32(fileMode), devices.Mkdev(node.MajorNumber, node.MinorNumber)); err != nil && err != syscall.EEXIST {
+		if err != syscall.EPERM {
+			return fmt.Errorf("mknod %s %s", node.Path, err)
+		}
 
-	if err := syscall.Chown(dest, int(node.Uid), int(node.Gid)); err != nil {
+		// mknod is not permitted in a user namespace or a nested container so the
+		// host's device node is bind mounted instead, keeping the host's ownership
+		if berr := bindMountDeviceNode(rootfs, node); berr != nil {
+			return fmt.Errorf("mknod %s %s, bind mount fallback %s", node.Path, err, berr)
+		}
+
+		return nil
+	}
+
+	if err := syscall.Fchownat(int(dir.Fd()), name, int(node.Uid), int(node.Gid), system.AT_SYMLINK_NOFOLLOW); err != nil {
 		return fmt.Errorf("chown %s to %d:%d", node.Path, node.Uid, node.Gid)
 	}
 
 	return nil
 }
+
+// bindMountDeviceNode bind mounts the device node at the same path on the host onto an
+// empty file in the rootfs.  The host's node has to be a device of the same type and
+// number so that the container does not get a different device than the one its
+// config allows.
+func bindMountDeviceNode(rootfs string, node *devices.Device) error {
+	var stat syscall.Stat_t
+	if err := syscall.Lstat(node.Path, &stat); err != nil {
+		return fmt.Errorf("lstat %s %s", node.Path, err)
+	}
+
+	var deviceType rune
+	switch stat.Mode & syscall.S_IFMT {
+	case syscall.S_IFCHR:
+		deviceType = 'c'
+	case syscall.S_IFBLK:
+		deviceType = 'b'
+	default:
+		return fmt.Errorf("%s on the host is not a device", node.Path)
+	}
+
+	rdev := int(stat.Rdev)
+	if deviceType != node.Type || devices.Major(rdev) != node.MajorNumber || devices.Minor(rdev) != node.MinorNumber {
+		return fmt.Errorf("%s on the host is %c %d:%d, not %c %d:%d", node.Path,
+			deviceType, devices.Major(rdev), devices.Minor(rdev), node.Type, node.MajorNumber, node.MinorNumber)
+	}
+
+	if err := system.CreateInRoot(rootfs, node.Path, false); err != nil {
+		return err
+	}
+
+	return system.WithProcfd(rootfs, node.Path, func(procfd string) error {
+		return syscall.Mount(node.Path, procfd, "bind", syscall.MS_BIND, "")
+	})
+}
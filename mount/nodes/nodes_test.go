@@ -0,0 +1,70 @@
+// +build linux
+
+package nodes
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/docker/libcontainer/devices"
+)
+
+func TestBindMountDeviceNodeRejectsMismatch(t *testing.T) {
+	rootfs, err := ioutil.TempDir("", "libcontainer-nodes-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootfs)
+
+	// /dev/null is the character device 1:3
+	for _, node := range []*devices.Device{
+		{Path: "/dev/null", Type: 'c', MajorNumber: 1, MinorNumber: 5},
+		{Path: "/dev/null", Type: 'b', MajorNumber: 1, MinorNumber: 3},
+		{Path: "/dev", Type: 'c', MajorNumber: 1, MinorNumber: 3},
+	} {
+		if err := bindMountDeviceNode(rootfs, node); err == nil {
+			t.Fatalf("expected %c %d:%d at %s to be rejected", node.Type, node.MajorNumber, node.MinorNumber, node.Path)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(rootfs, "dev")); err == nil {
+		t.Fatal("expected no target to be created for a rejected device")
+	}
+}
+
+func TestCreateDeviceNodeFallsBackToBindMount(t *testing.T) {
+	if testing.Short() || os.Getuid() != 0 {
+		t.Skip("requires root to create mounts")
+	}
+
+	rootfs, err := ioutil.TempDir("", "libcontainer-nodes-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(rootfs)
+
+	// fail mknod the same way as in a user namespace
+	mknodat = func(dirfd int, path string, mode uint32, dev int) error {
+		return syscall.EPERM
+	}
+	defer func() { mknodat = syscall.Mknodat }()
+
+	node := &devices.Device{Path: "/dev/null", Type: 'c', MajorNumber: 1, MinorNumber: 3, FileMode: 0666}
+	if err := CreateDeviceNode(rootfs, node); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(rootfs, "dev", "null")
+	defer syscall.Unmount(dest, syscall.MNT_DETACH)
+
+	var stat syscall.Stat_t
+	if err := syscall.Stat(dest, &stat); err != nil {
+		t.Fatal(err)
+	}
+	if stat.Mode&syscall.S_IFMT != syscall.S_IFCHR || devices.Major(int(stat.Rdev)) != 1 || devices.Minor(int(stat.Rdev)) != 3 {
+		t.Fatalf("expected the host's /dev/null to be bind mounted at %s", dest)
+	}
+}
@@ -0,0 +1,151 @@
+// +build linux
+
+package mount
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+
+	"github.com/docker/libcontainer/label"
+)
+
+// mountFlag is a mount option that sets or clears a mount flag
+type mountFlag struct {
+	clear bool
+	flag  int
+}
+
+// mountFlags maps the filesystem independent mount options to their flags the same
+// way that mount(8) does.  Any other option that is not a propagation mode is passed
+// to the filesystem as data.
+var mountFlags = map[string]mountFlag{
+	"async":         {true, syscall.MS_SYNCHRONOUS},
+	"atime":         {true, syscall.MS_NOATIME},
+	"bind":          {false, syscall.MS_BIND},
+	"defaults":      {false, 0},
+	"dev":           {true, syscall.MS_NODEV},
+	"diratime":      {true, syscall.MS_NODIRATIME},
+	"dirsync":       {false, syscall.MS_DIRSYNC},
+	"exec":          {true, syscall.MS_NOEXEC},
+	"mand":          {false, syscall.MS_MANDLOCK},
+	"noatime":       {false, syscall.MS_NOATIME},
+	"nodev":         {false, syscall.MS_NODEV},
+	"nodiratime":    {false, syscall.MS_NODIRATIME},
+	"noexec":        {false, syscall.MS_NOEXEC},
+	"nomand":        {true, syscall.MS_MANDLOCK},
+	"norelatime":    {true, syscall.MS_RELATIME},
+	"nostrictatime": {true, syscall.MS_STRICTATIME},
+	"nosuid":        {false, syscall.MS_NOSUID},
+	"rbind":         {false, syscall.MS_BIND | syscall.MS_REC},
+	"relatime":      {false, syscall.MS_RELATIME},
+	"remount":       {false, syscall.MS_REMOUNT},
+	"ro":            {false, syscall.MS_RDONLY},
+	"rw":            {true, syscall.MS_RDONLY},
+	"strictatime":   {false, syscall.MS_STRICTATIME},
+	"suid":          {true, syscall.MS_NOSUID},
+	"sync":          {false, syscall.MS_SYNCHRONOUS},
+}
+
+// propagationFlags maps the propagation modes to the flags used to change the
+// propagation type of a mount, the r prefixed modes apply to all submounts as well
+var propagationFlags = map[string]int{
+	"private":     syscall.MS_PRIVATE,
+	"rprivate":    syscall.MS_PRIVATE | syscall.MS_REC,
+	"slave":       syscall.MS_SLAVE,
+	"rslave":      syscall.MS_SLAVE | syscall.MS_REC,
+	"shared":      syscall.MS_SHARED,
+	"rshared":     syscall.MS_SHARED | syscall.MS_REC,
+	"unbindable":  syscall.MS_UNBINDABLE,
+	"runbindable": syscall.MS_UNBINDABLE | syscall.MS_REC,
+}
+
+// setPropagation changes the propagation type of the mount at path.  The propagation
+// type has to be changed after a mount is created as the kernel ignores any other
+// flags when a propagation flag is passed to mount(2).
+func setPropagation(path, propagation string) error {
+	if propagation == "" {
+		return nil
+	}
+
+	flags, exists := propagationFlags[propagation]
+	if !exists {
+		return fmt.Errorf("invalid mount propagation %q", propagation)
+	}
+
+	if err := syscall.Mount("", path, "none", uintptr(flags), ""); err != nil {
+		return fmt.Errorf("mounting %s %s %s", path, propagation, err)
+	}
+
+	return nil
+}
+
+// ignoredOptions are the mount(8) options that only affect mount(8) itself, such as
+// whether a mount in fstab is mounted at boot, so they are neither flags nor data
+var ignoredOptions = map[string]bool{
+	"auto":    true,
+	"noauto":  true,
+	"user":    true,
+	"nouser":  true,
+	"users":   true,
+	"owner":   true,
+	"group":   true,
+	"nofail":  true,
+	"_netdev": true,
+}
+
+// parseOptions parses the mount options into the mount flags, the propagation mode and
+// the filesystem specific data which is appended to any existing data.  When more than
+// one propagation mode is given the last one is used.
+func parseOptions(options []string, data string) (int, string, string) {
+	var (
+		flags       int
+		propagation string
+		extra       []string
+	)
+
+	if data != "" {
+		extra = append(extra, data)
+	}
+
+	for _, o := range options {
+		if _, exists := propagationFlags[o]; exists {
+			propagation = o
+			continue
+		}
+
+		if ignoredOptions[o] || strings.HasPrefix(o, "x-") {
+			continue
+		}
+
+		f, exists := mountFlags[o]
+		if !exists {
+			extra = append(extra, o)
+			continue
+		}
+
+		if f.clear {
+			flags &^= f.flag
+		} else {
+			flags |= f.flag
+		}
+	}
+
+	return flags, propagation, strings.Join(extra, ",")
+}
+
+// unlabeledFilesystems are the filesystems that do not support the context mount option
+var unlabeledFilesystems = map[string]bool{
+	"proc":   true,
+	"sysfs":  true,
+	"mqueue": true,
+}
+
+// formatMountLabel adds the mount label to the data of a mount of the filesystem type
+// if the filesystem supports it
+func formatMountLabel(fstype, data, mountLabel string) string {
+	if unlabeledFilesystems[fstype] {
+		return data
+	}
+	return label.FormatMountLabel(data, mountLabel)
+}
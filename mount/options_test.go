@@ -0,0 +1,73 @@
+// +build linux
+
+package mount
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		options     []string
+		data        string
+		flags       int
+		propagation string
+		expected    string
+	}{
+		{nil, "", 0, "", ""},
+		{[]string{"defaults"}, "", 0, "", ""},
+		{[]string{"nosuid", "nodev", "ro"}, "", syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_RDONLY, "", ""},
+		{[]string{"ro", "rw"}, "", 0, "", ""},
+		{[]string{"noexec", "exec", "nosuid"}, "", syscall.MS_NOSUID, "", ""},
+		{[]string{"rbind"}, "", syscall.MS_BIND | syscall.MS_REC, "", ""},
+		{[]string{"nosuid", "mode=755", "size=65536k"}, "", syscall.MS_NOSUID, "", "mode=755,size=65536k"},
+		{[]string{"newinstance"}, "gid=5", 0, "", "gid=5,newinstance"},
+		{[]string{"rshared"}, "", 0, "rshared", ""},
+		{[]string{"ro", "private", "mode=755"}, "", syscall.MS_RDONLY, "private", "mode=755"},
+		{[]string{"slave", "runbindable"}, "", 0, "runbindable", ""},
+		{[]string{"noauto", "nofail", "x-systemd.automount", "nodev"}, "", syscall.MS_NODEV, "", ""},
+	}
+
+	for i, test := range tests {
+		flags, propagation, data := parseOptions(test.options, test.data)
+		if flags != test.flags {
+			t.Errorf("test %d: expected flags %X but received %X", i, test.flags, flags)
+		}
+		if propagation != test.propagation {
+			t.Errorf("test %d: expected propagation %q but received %q", i, test.propagation, propagation)
+		}
+		if data != test.expected {
+			t.Errorf("test %d: expected data %q but received %q", i, test.expected, data)
+		}
+	}
+}
+
+func TestFormatMountLabel(t *testing.T) {
+	const mountLabel = "system_u:object_r:svirt_sandbox_file_t:s0:c1,c2"
+
+	for _, fstype := range []string{"proc", "sysfs", "mqueue"} {
+		if data := formatMountLabel(fstype, "mode=755", mountLabel); data != "mode=755" {
+			t.Errorf("expected %s to be mounted without the label, got data %q", fstype, data)
+		}
+	}
+}
+
+func TestMountPropagationFromOptions(t *testing.T) {
+	tests := []struct {
+		mount    *Mount
+		expected string
+	}{
+		{&Mount{Options: []string{"rshared"}}, "rshared"},
+		{&Mount{Options: []string{"rshared"}, Propagation: "private"}, "private"},
+		{&Mount{Options: []string{"nosuid"}, Slave: true}, "slave"},
+		{&Mount{Options: []string{"slave"}, Private: true}, "slave"},
+		{&Mount{}, ""},
+	}
+
+	for i, test := range tests {
+		if propagation := test.mount.propagation(); propagation != test.expected {
+			t.Errorf("test %d: expected propagation %q but received %q", i, test.expected, propagation)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// +build linux
+
+package mount
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/docker/libcontainer/label"
+)
+
+// mountRootfsLayers mounts an overlay of the layers on top of the rootfs so that the
+// mount can be used in place of the rootfs bind mount
+func mountRootfsLayers(rootfs string, layers *RootfsLayers, mountLabel string) error {
+	data, err := overlayData(layers)
+	if err != nil {
+		return err
+	}
+
+	if layers.Upper != "" {
+		if err := os.MkdirAll(layers.Upper, 0755); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(layers.Work, 0755); err != nil {
+			return err
+		}
+	}
+
+	if err := syscall.Mount("overlay", rootfs, "overlay", 0, label.FormatMountLabel(data, mountLabel)); err != nil {
+		// the overlay module is loaded on demand so it is only known to be missing once the mount fails
+		if err == syscall.ENODEV {
+			return fmt.Errorf("the kernel does not support overlay, prepare the rootfs as a directory instead")
+		}
+		return fmt.Errorf("mounting overlay on %s %s", rootfs, err)
+	}
+
+	return nil
+}
+
+// overlayData returns the overlay mount data for the layers
+func overlayData(layers *RootfsLayers) (string, error) {
+	if len(layers.Lower) == 0 {
+		return "", fmt.Errorf("no lower layers specified")
+	}
+
+	if layers.Upper == "" && len(layers.Lower) < 2 {
+		return "", fmt.Errorf("at least two lower layers are required without an upper layer")
+	}
+
+	if layers.Upper != "" && layers.Work == "" {
+		return "", fmt.Errorf("a work directory is required with an upper layer")
+	}
+
+	// the separators of the mount data can not be escaped
+	for _, path := range append([]string{layers.Upper, layers.Work}, layers.Lower...) {
+		if strings.ContainsAny(path, ",:") {
+			return "", fmt.Errorf("layer path %q contains a ',' or ':'", path)
+		}
+	}
+
+	data := "lowerdir=" + strings.Join(layers.Lower, ":")
+	if layers.Upper != "" {
+		data += fmt.Sprintf(",upperdir=%s,workdir=%s", layers.Upper, layers.Work)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,50 @@
+// +build linux
+
+package mount
+
+import "testing"
+
+func TestOverlayData(t *testing.T) {
+	tests := []struct {
+		layers   *RootfsLayers
+		expected string
+	}{
+		{
+			&RootfsLayers{Lower: []string{"/layers/app", "/layers/base"}},
+			"lowerdir=/layers/app:/layers/base",
+		},
+		{
+			&RootfsLayers{Lower: []string{"/layers/base"}, Upper: "/layers/upper", Work: "/layers/work"},
+			"lowerdir=/layers/base,upperdir=/layers/upper,workdir=/layers/work",
+		},
+	}
+
+	for i, test := range tests {
+		data, err := overlayData(test.layers)
+		if err != nil {
+			t.Fatalf("test %d: %s", i, err)
+		}
+		if data != test.expected {
+			t.Fatalf("test %d: expected data %q but received %q", i, test.expected, data)
+		}
+	}
+}
+
+func TestOverlayDataInvalid(t *testing.T) {
+	invalid := []*RootfsLayers{
+		{},
+		{Lower: []string{}, Upper: "/layers/upper", Work: "/layers/work"},
+		{Lower: []string{"/layers/base"}},
+		{Lower: []string{"/layers/base"}, Upper: "/layers/upper"},
+		{Lower: []string{"/layers/app", "/layers/base,upperdir=/tmp"}},
+		{Lower: []string{"/layers/app", "/layers/base:/"}},
+		{Lower: []string{"/layers/base"}, Upper: "/layers/upper,lowerdir=/", Work: "/layers/work"},
+		{Lower: []string{"/layers/base"}, Upper: "/layers/upper", Work: "/layers/work:1"},
+	}
+
+	for i, layers := range invalid {
+		if _, err := overlayData(layers); err == nil {
+			t.Fatalf("test %d: expected layers %+v to be rejected", i, layers)
+		}
+	}
+}
@@ -26,6 +26,13 @@ func PivotRoot(rootfs string) error {
 
 	// path to pivot dir now changed, update
 	pivotDir = filepath.Join("/", filepath.Base(pivotDir))
+
+	// make sure that unmounting the old root does not propagate to the host when the
+	// root propagation is shared
+	if err := syscall.Mount("", pivotDir, "", syscall.MS_SLAVE|syscall.MS_REC, ""); err != nil {
+		return fmt.Errorf("mounting pivot_root dir rslave %s", err)
+	}
+
 	if err := syscall.Unmount(pivotDir, syscall.MNT_DETACH); err != nil {
 		return fmt.Errorf("unmount pivot_root dir %s", err)
 	}
@@ -3,9 +3,63 @@
 package mount
 
 import (
+	"fmt"
+	"path/filepath"
+	"strings"
 	"syscall"
+
+	"github.com/docker/libcontainer/system"
 )
 
+// the flags of an existing mount that have to be kept when it is remounted, the kernel
+// refuses to clear flags that are locked for a mount in a user namespace
+const preservedMountFlags = syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_NOEXEC |
+	syscall.MS_NOATIME | syscall.MS_NODIRATIME | syscall.MS_RELATIME | syscall.MS_STRICTATIME
+
+// mountSetattr is replaced in tests to exercise the mountinfo fallback
+var mountSetattr = system.MountSetattr
+
 func SetReadonly() error {
 	return syscall.Mount("/", "/", "bind", syscall.MS_BIND|syscall.MS_REMOUNT|syscall.MS_RDONLY|syscall.MS_REC, "")
 }
+
+// remountReadonlyRecursive makes the mount at path and all of its submounts readonly.
+// A remount with MS_RDONLY only applies to a single mount so kernels without
+// mount_setattr have each submount found in /proc/self/mountinfo remounted.  The
+// submounts are opened inside the mount so that a symlink swapped in for one of
+// them can not redirect the remount.
+func remountReadonlyRecursive(path string) error {
+	err := mountSetattr(path, system.AT_RECURSIVE, &system.MountAttr{AttrSet: system.MOUNT_ATTR_RDONLY})
+	if err == nil {
+		return nil
+	}
+	if err != syscall.ENOSYS {
+		return fmt.Errorf("mount_setattr %s readonly %s", path, err)
+	}
+
+	if path, err = filepath.EvalSymlinks(path); err != nil {
+		return err
+	}
+
+	mounts, err := getMountInfo()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range mounts {
+		if m.mountpoint != path && !strings.HasPrefix(m.mountpoint, path+"/") {
+			continue
+		}
+
+		flags, _, _ := parseOptions(strings.Split(m.options, ","), "")
+		flags = flags&preservedMountFlags | syscall.MS_BIND | syscall.MS_REMOUNT | syscall.MS_RDONLY
+
+		if err := system.WithProcfd(path, filepath.Join("/", strings.TrimPrefix(m.mountpoint, path)), func(procfd string) error {
+			return syscall.Mount("", procfd, "", uintptr(flags), "")
+		}); err != nil {
+			return fmt.Errorf("remounting %s readonly %s", m.mountpoint, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,95 @@
+// +build linux
+
+package mount
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/docker/libcontainer/system"
+)
+
+func TestParseMountInfo(t *testing.T) {
+	data := `36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+37 36 0:32 / /mnt2/with\040space ro,nosuid,nodev - tmpfs tmpfs rw
+`
+	mounts, err := parseMountInfo(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(mounts) != 2 {
+		t.Fatalf("expected 2 mounts, got %d", len(mounts))
+	}
+
+	if m := mounts[1]; m.id != 37 || m.parent != 36 || m.mountpoint != "/mnt2/with space" || m.options != "ro,nosuid,nodev" {
+		t.Fatalf("unexpected mount %+v", m)
+	}
+}
+
+func TestBindMountReadonlyRecursive(t *testing.T) {
+	testBindMountReadonlyRecursive(t)
+}
+
+func TestBindMountReadonlyRecursiveWithoutMountSetattr(t *testing.T) {
+	mountSetattr = func(string, int, *system.MountAttr) error {
+		return syscall.ENOSYS
+	}
+	defer func() { mountSetattr = system.MountSetattr }()
+
+	testBindMountReadonlyRecursive(t)
+}
+
+func testBindMountReadonlyRecursive(t *testing.T) {
+	if testing.Short() || os.Getuid() != 0 {
+		t.Skip("requires root to create mounts")
+	}
+
+	dir, err := ioutil.TempDir("", "libcontainer-readonly-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		source = filepath.Join(dir, "source")
+		nested = filepath.Join(source, "nested")
+		rootfs = filepath.Join(dir, "rootfs")
+	)
+
+	for _, p := range []string{nested, rootfs} {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := syscall.Mount("tmpfs", nested, "tmpfs", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Unmount(nested, syscall.MNT_DETACH)
+
+	m := &Mount{Type: "bind", Source: source, Destination: "/data"}
+	if err := m.Mount(rootfs, ""); err != nil {
+		t.Fatal(err)
+	}
+	defer syscall.Unmount(filepath.Join(rootfs, "data"), syscall.MNT_DETACH)
+
+	for _, p := range []string{"data/file", "data/nested/file"} {
+		err := ioutil.WriteFile(filepath.Join(rootfs, p), []byte("data"), 0644)
+		if err == nil {
+			t.Fatalf("expected %s to be readonly", p)
+		}
+		if !os.IsPermission(err) && !strings.Contains(err.Error(), syscall.EROFS.Error()) {
+			t.Fatalf("expected a readonly filesystem error for %s, got %s", p, err)
+		}
+	}
+
+	// the source's submount stays writable outside of the container
+	if err := ioutil.WriteFile(filepath.Join(nested, "file"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,69 @@
+// +build linux
+
+package mount
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// TestMountThroughMaliciousSymlink checks that the mount targets are resolved inside the
+// rootfs.  The resolution itself is covered by the tests of the system package.
+func TestMountThroughMaliciousSymlink(t *testing.T) {
+	if testing.Short() || os.Getuid() != 0 {
+		t.Skip("requires root to create mounts")
+	}
+
+	mounts := map[string]func(rootfs, source string) error{
+		"bind": func(rootfs, source string) error {
+			m := &Mount{Type: "bind", Source: source, Destination: "/escape"}
+			return m.Mount(rootfs, "")
+		},
+		"system": func(rootfs, source string) error {
+			return mountSystem(rootfs, false, &MountConfig{
+				SystemMounts: []*Mount{{Type: "tmpfs", Source: "tmpfs", Destination: "/escape"}},
+			})
+		},
+	}
+
+	for name, mount := range mounts {
+		dir, err := ioutil.TempDir("", "libcontainer-secure-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		var (
+			rootfs  = filepath.Join(dir, "rootfs")
+			outside = filepath.Join(dir, "outside")
+			source  = filepath.Join(dir, "source")
+		)
+		for _, p := range []string{rootfs, outside, source} {
+			if err := os.MkdirAll(p, 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := ioutil.WriteFile(filepath.Join(outside, "marker"), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(outside, filepath.Join(rootfs, "escape")); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := mount(rootfs, source); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		defer syscall.Unmount(filepath.Join(rootfs, outside), syscall.MNT_DETACH)
+
+		if _, err := os.Stat(filepath.Join(outside, "marker")); err != nil {
+			syscall.Unmount(outside, syscall.MNT_DETACH)
+			t.Fatalf("%s: the mount followed the symlink out of the rootfs", name)
+		}
+		if mountpoint, err := findMountPoint(filepath.Join(rootfs, outside)); err != nil || mountpoint != filepath.Join(rootfs, outside) {
+			t.Fatalf("%s: expected the mount inside the rootfs", name)
+		}
+	}
+}
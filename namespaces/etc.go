@@ -0,0 +1,119 @@
+// +build linux
+
+package namespaces
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/mount"
+)
+
+// etcFiles generates the container's /etc/hostname, /etc/hosts and /etc/resolv.conf in the
+// etc directory of the container's data path and returns the bind mounts for them.  Each file
+// is only generated when the config sets it up, the rootfs's own file is used otherwise.  The
+// files are left in the data path after the container exits.
+func etcFiles(container *libcontainer.Config, dataPath string) ([]*mount.Mount, error) {
+	generateHostsFile := container.GenerateHosts || len(container.Hosts) > 0
+
+	if container.Hostname == "" && !generateHostsFile && container.Dns == nil {
+		return nil, nil
+	}
+
+	if dataPath == "" {
+		if generateHostsFile || container.Dns != nil {
+			return nil, fmt.Errorf("the container's hosts and dns settings require a data path to generate /etc/hosts and /etc/resolv.conf in")
+		}
+		return nil, nil
+	}
+
+	dir := filepath.Join(dataPath, "etc")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	files := make(map[string][]byte)
+	if generateHostsFile {
+		files["hosts"] = generateHosts(container)
+	}
+	if container.Hostname != "" {
+		files["hostname"] = []byte(container.Hostname + "\n")
+	}
+	if container.Dns != nil {
+		files["resolv.conf"] = generateResolvConf(container.Dns)
+	}
+
+	var mounts []*mount.Mount
+	for _, name := range []string{"hostname", "hosts", "resolv.conf"} {
+		data, exists := files[name]
+		if !exists {
+			continue
+		}
+
+		source := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(source, data, 0644); err != nil {
+			return nil, err
+		}
+
+		mounts = append(mounts, &mount.Mount{
+			Type:        "bind",
+			Source:      source,
+			Destination: filepath.Join("/etc", name),
+			Writable:    true,
+			Private:     true,
+		})
+	}
+
+	return mounts, nil
+}
+
+// generateHosts returns the container's /etc/hosts with its hostname resolving to the
+// address of its veth interface, or to 127.0.1.1 without one
+func generateHosts(container *libcontainer.Config) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "127.0.0.1\tlocalhost\n")
+	fmt.Fprintf(&buf, "::1\tlocalhost ip6-localhost ip6-loopback\n")
+
+	if container.Hostname != "" {
+		address := "127.0.1.1"
+		for _, n := range container.Networks {
+			if n.Type != "veth" || n.Address == "" {
+				continue
+			}
+			if ip, _, err := net.ParseCIDR(n.Address); err == nil {
+				address = ip.String()
+				break
+			}
+		}
+		fmt.Fprintf(&buf, "%s\t%s\n", address, container.Hostname)
+	}
+
+	for _, h := range container.Hosts {
+		fmt.Fprintf(&buf, "%s\t%s\n", h.Address, strings.Join(h.Hostnames, " "))
+	}
+
+	return buf.Bytes()
+}
+
+func generateResolvConf(dns *libcontainer.Dns) []byte {
+	var buf bytes.Buffer
+
+	for _, s := range dns.Servers {
+		fmt.Fprintf(&buf, "nameserver %s\n", s)
+	}
+	if len(dns.Search) > 0 {
+		fmt.Fprintf(&buf, "search %s\n", strings.Join(dns.Search, " "))
+	}
+	if len(dns.Options) > 0 {
+		fmt.Fprintf(&buf, "options %s\n", strings.Join(dns.Options, " "))
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,126 @@
+// +build linux
+
+package namespaces
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/docker/libcontainer"
+)
+
+const localhostEntries = "127.0.0.1\tlocalhost\n::1\tlocalhost ip6-localhost ip6-loopback\n"
+
+func TestGenerateHosts(t *testing.T) {
+	tests := []struct {
+		container *libcontainer.Config
+		expected  string
+	}{
+		{
+			&libcontainer.Config{},
+			localhostEntries,
+		},
+		{
+			&libcontainer.Config{Hostname: "koye"},
+			localhostEntries + "127.0.1.1\tkoye\n",
+		},
+		{
+			&libcontainer.Config{
+				Hostname: "koye",
+				Networks: []*libcontainer.Network{
+					{Type: "loopback", Address: "127.0.0.1/0"},
+					{Type: "veth", Address: "172.17.0.101/16"},
+				},
+			},
+			localhostEntries + "172.17.0.101\tkoye\n",
+		},
+		{
+			&libcontainer.Config{
+				Hosts: []*libcontainer.HostEntry{
+					{Address: "10.0.0.1", Hostnames: []string{"db", "db.local"}},
+					{Address: "fd00::1", Hostnames: []string{"cache"}},
+				},
+			},
+			localhostEntries + "10.0.0.1\tdb db.local\nfd00::1\tcache\n",
+		},
+	}
+
+	for i, test := range tests {
+		if hosts := string(generateHosts(test.container)); hosts != test.expected {
+			t.Fatalf("test %d: expected hosts %q but received %q", i, test.expected, hosts)
+		}
+	}
+}
+
+func TestGenerateResolvConf(t *testing.T) {
+	tests := []struct {
+		dns      *libcontainer.Dns
+		expected string
+	}{
+		{
+			&libcontainer.Dns{},
+			"",
+		},
+		{
+			&libcontainer.Dns{Servers: []string{"8.8.8.8", "8.8.4.4"}},
+			"nameserver 8.8.8.8\nnameserver 8.8.4.4\n",
+		},
+		{
+			&libcontainer.Dns{
+				Servers: []string{"10.0.0.2"},
+				Search:  []string{"example.com", "local"},
+				Options: []string{"ndots:2", "timeout:1"},
+			},
+			"nameserver 10.0.0.2\nsearch example.com local\noptions ndots:2 timeout:1\n",
+		},
+	}
+
+	for i, test := range tests {
+		if resolvConf := string(generateResolvConf(test.dns)); resolvConf != test.expected {
+			t.Fatalf("test %d: expected resolv.conf %q but received %q", i, test.expected, resolvConf)
+		}
+	}
+}
+
+func TestEtcFiles(t *testing.T) {
+	tests := []struct {
+		container *libcontainer.Config
+		expected  []string
+	}{
+		{&libcontainer.Config{}, nil},
+		{&libcontainer.Config{Hostname: "koye"}, []string{"/etc/hostname"}},
+		{&libcontainer.Config{Hostname: "koye", GenerateHosts: true}, []string{"/etc/hostname", "/etc/hosts"}},
+		{
+			&libcontainer.Config{Hosts: []*libcontainer.HostEntry{{Address: "10.0.0.1", Hostnames: []string{"db"}}}},
+			[]string{"/etc/hosts"},
+		},
+		{&libcontainer.Config{Dns: &libcontainer.Dns{Servers: []string{"8.8.8.8"}}}, []string{"/etc/resolv.conf"}},
+	}
+
+	for i, test := range tests {
+		dataPath, err := ioutil.TempDir("", "libcontainer-etc-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dataPath)
+
+		mounts, err := etcFiles(test.container, dataPath)
+		if err != nil {
+			t.Fatalf("test %d: %s", i, err)
+		}
+
+		var destinations []string
+		for _, m := range mounts {
+			destinations = append(destinations, m.Destination)
+		}
+		if len(destinations) != len(test.expected) {
+			t.Fatalf("test %d: expected mounts %v but received %v", i, test.expected, destinations)
+		}
+		for j := range destinations {
+			if destinations[j] != test.expected[j] {
+				t.Fatalf("test %d: expected mounts %v but received %v", i, test.expected, destinations)
+			}
+		}
+	}
+}
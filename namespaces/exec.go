@@ -4,10 +4,13 @@ package namespaces
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"syscall"
+	"time"
 
 	"github.com/docker/libcontainer"
 	"github.com/docker/libcontainer/cgroups"
@@ -25,9 +28,18 @@ const (
 // Move this to libcontainer package.
 // Exec performs setup outside of a namespace so that a container can be
 // executed.  Exec is a high level function for working with container namespaces.
+// Callers that lock the container's state are expected to hold the lock until
+// startCallback is called.
 func Exec(container *libcontainer.Config, stdin io.Reader, stdout, stderr io.Writer, console, dataPath string, args []string, createCommand CreateCommand, startCallback func()) (int, error) {
 	var err error
 
+	// the init runs inside the rootfs so the data path that it generates the container's
+	// /etc files in has to be absolute.  An empty data path is the current directory, the
+	// same as for the container's state.
+	if dataPath, err = filepath.Abs(dataPath); err != nil {
+		return -1, err
+	}
+
 	// create a pipe so that we can syncronize with the namespaced process and
 	// pass the state and configuration to the child process
 	parent, child, err := newInitPipe()
@@ -36,6 +48,12 @@ func Exec(container *libcontainer.Config, stdin io.Reader, stdout, stderr io.Wri
 	}
 	defer parent.Close()
 
+	if container.Subreaper {
+		if err := system.SetSubreaper(1); err != nil {
+			return -1, err
+		}
+	}
+
 	command := createCommand(container, console, dataPath, os.Args[0], child, args)
 	// Note: these are only used in non-tty mode
 	// if there is a tty for the container it will be opened within the namespace and the
@@ -88,12 +106,23 @@ func Exec(container *libcontainer.Config, stdin io.Reader, stdout, stderr io.Wri
 		InitStartTime: started,
 		NetworkState:  networkState,
 		CgroupPaths:   cgroupPaths,
+		Created:       time.Now(),
 	}
 
 	if err := libcontainer.SaveState(dataPath, state); err != nil {
 		return terminate(err)
 	}
-	defer libcontainer.DeleteState(dataPath)
+
+	running := false
+	defer func() {
+		// the caller holds the lock until the container has started
+		if running {
+			if lock, err := libcontainer.LockState(dataPath); err == nil {
+				defer lock.Unlock()
+			}
+		}
+		libcontainer.DeleteState(dataPath)
+	}()
 
 	// wait for the child process to fully complete and receive an error message
 	// if one was encoutered
@@ -108,6 +137,12 @@ func Exec(container *libcontainer.Config, stdin io.Reader, stdout, stderr io.Wri
 	if startCallback != nil {
 		startCallback()
 	}
+	running = true
+
+	if container.Subreaper {
+		stop := reapOrphans(command.Process.Pid)
+		defer stop()
+	}
 
 	if err := command.Wait(); err != nil {
 		if _, ok := err.(*exec.ExitError); !ok {
@@ -128,33 +163,55 @@ func Exec(container *libcontainer.Config, stdin io.Reader, stdout, stderr io.Wri
 // killAllPids itterates over all of the container's processes
 // sending a SIGKILL to each process.
 func killAllPids(container *libcontainer.Config) error {
+	procs, err := SignalAllPids(container, syscall.SIGKILL)
+	for _, p := range procs {
+		p.Wait()
+	}
+	return err
+}
+
+// SignalAllPids sends the signal to all of the container's processes.  The container is frozen
+// while the signals are sent so that no process can fork and escape the signal, and it is left
+// frozen afterwards if it was already paused.  The processes that were signaled are returned so
+// that they can be waited on by the caller.
+func SignalAllPids(container *libcontainer.Config, sig os.Signal) ([]*os.Process, error) {
+	if container.Cgroups == nil {
+		return nil, fmt.Errorf("container does not have a cgroups config")
+	}
+
 	var (
-		procs   []*os.Process
-		freeze  = fs.Freeze
-		getPids = fs.GetPids
+		procs           []*os.Process
+		freeze          = fs.Freeze
+		getFreezerState = fs.GetFreezerState
+		getPids         = fs.GetPids
 	)
 	if systemd.UseSystemd() {
 		freeze = systemd.Freeze
+		getFreezerState = systemd.GetFreezerState
 		getPids = systemd.GetPids
 	}
-	freeze(container.Cgroups, cgroups.Frozen)
+
+	// without a freezer cgroup the signals are sent without freezing the container
+	if state, err := getFreezerState(container.Cgroups); err == nil {
+		freeze(container.Cgroups, cgroups.Frozen)
+		if state == cgroups.Thawed {
+			defer freeze(container.Cgroups, cgroups.Thawed)
+		}
+	}
+
 	pids, err := getPids(container.Cgroups)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	for _, pid := range pids {
 		// TODO: log err without aborting if we are unable to find
 		// a single PID
 		if p, err := os.FindProcess(pid); err == nil {
 			procs = append(procs, p)
-			p.Kill()
+			p.Signal(sig)
 		}
 	}
-	freeze(container.Cgroups, cgroups.Thawed)
-	for _, p := range procs {
-		p.Wait()
-	}
-	return err
+	return procs, nil
 }
 
 // DefaultCreateCommand will return an exec.Cmd with the Cloneflags set to the proper namespaces
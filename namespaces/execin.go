@@ -101,6 +101,10 @@ func FinalizeSetns(container *libcontainer.Config, args []string) error {
 		return fmt.Errorf("setup rlimits %s", err)
 	}
 
+	if err := setupScheduling(container); err != nil {
+		return fmt.Errorf("setup scheduling %s", err)
+	}
+
 	if err := FinalizeNamespace(container); err != nil {
 		return err
 	}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"syscall"
 
@@ -53,6 +55,13 @@ func Init(container *libcontainer.Config, uncleanRootfs, consolePath string, pip
 		return err
 	}
 
+	// the data path is set by DefaultCreateCommand and has to be read before the
+	// container's environment replaces ours
+	etcMounts, err := etcFiles(container, os.Getenv("data_path"))
+	if err != nil {
+		return fmt.Errorf("generate /etc files %s", err)
+	}
+
 	// clear the current processes env and replace it with the environment
 	// defined on the container
 	if err := LoadContainerEnvironment(container); err != nil {
@@ -93,19 +102,34 @@ func Init(container *libcontainer.Config, uncleanRootfs, consolePath string, pip
 		return fmt.Errorf("setup rlimits %s", err)
 	}
 
+	if err := setupScheduling(container); err != nil {
+		return fmt.Errorf("setup scheduling %s", err)
+	}
+
 	label.Init()
 
+	// the generated /etc files are mounted last so that a mount of the container's /etc
+	// does not hide them
+	mountConfig := *(*mount.MountConfig)(container.MountConfig)
+	mountConfig.Mounts = append(append([]*mount.Mount{}, mountConfig.Mounts...), etcMounts...)
+
 	if err := mount.InitializeMountNamespace(rootfs,
 		consolePath,
 		container.RestrictSys,
-		(*mount.MountConfig)(container.MountConfig)); err != nil {
+		&mountConfig); err != nil {
 		return fmt.Errorf("setup mount namespace %s", err)
 	}
 
-	if container.Hostname != "" {
-		if err := syscall.Sethostname([]byte(container.Hostname)); err != nil {
-			return fmt.Errorf("unable to sethostname %q: %s", container.Hostname, err)
-		}
+	if err := setupUts(container); err != nil {
+		return err
+	}
+
+	if err := setupSysctl(container); err != nil {
+		return fmt.Errorf("setup sysctl %s", err)
+	}
+
+	if err := setupTimeNamespace(container); err != nil {
+		return fmt.Errorf("setup time namespace %s", err)
 	}
 
 	if err := apparmor.ApplyProfile(container.AppArmorProfile); err != nil {
@@ -116,13 +140,20 @@ func Init(container *libcontainer.Config, uncleanRootfs, consolePath string, pip
 		return fmt.Errorf("set process label %s", err)
 	}
 
-	// TODO: (crosbymichael) make this configurable at the Config level
+	readonlyPaths, maskedPaths := container.ReadonlyPaths, container.MaskedPaths
 	if container.RestrictSys {
-		if err := restrict.Restrict("proc/sys", "proc/sysrq-trigger", "proc/irq", "proc/bus"); err != nil {
-			return err
+		if readonlyPaths == nil {
+			readonlyPaths = restrict.DefaultReadonlyPaths
+		}
+		if maskedPaths == nil {
+			maskedPaths = restrict.DefaultMaskedPaths
 		}
 	}
 
+	if err := restrict.Restrict(readonlyPaths, maskedPaths); err != nil {
+		return err
+	}
+
 	pdeathSignal, err := system.GetParentDeathSignal()
 	if err != nil {
 		return fmt.Errorf("get parent death signal %s", err)
@@ -138,6 +169,13 @@ func Init(container *libcontainer.Config, uncleanRootfs, consolePath string, pip
 		return fmt.Errorf("restore parent death signal %s", err)
 	}
 
+	if container.BuiltinInit {
+		if !container.Namespaces.Contains(libcontainer.NEWPID) {
+			return fmt.Errorf("builtin init requires the %s namespace", libcontainer.NEWPID)
+		}
+		return runReapingInit(args, consolePath != "", pipe)
+	}
+
 	return system.Execv(args[0], args[0:], os.Environ())
 }
 
@@ -247,13 +285,255 @@ func setupRoute(container *libcontainer.Config) error {
 func setupRlimits(container *libcontainer.Config) error {
 	for _, rlimit := range container.Rlimits {
 		l := &syscall.Rlimit{Max: rlimit.Hard, Cur: rlimit.Soft}
-		if err := syscall.Setrlimit(rlimit.Type, l); err != nil {
+		if err := syscall.Setrlimit(int(rlimit.Type), l); err != nil {
 			return fmt.Errorf("error setting rlimit type %v: %v", rlimit.Type, err)
 		}
 	}
 	return nil
 }
 
+// namespacedSysctls are the kernel parameters outside of the net.* tree that are isolated by
+// the ipc namespace
+var namespacedSysctls = map[string]bool{
+	"kernel.msgmax":          true,
+	"kernel.msgmnb":          true,
+	"kernel.msgmni":          true,
+	"kernel.sem":             true,
+	"kernel.shmall":          true,
+	"kernel.shmmax":          true,
+	"kernel.shmmni":          true,
+	"kernel.shm_rmid_forced": true,
+}
+
+// validateSysctl ensures that the kernel parameter is isolated by one of the container's
+// namespaces so that setting it does not change the value for the host.  It returns the
+// path of the parameter under /proc/sys.
+func validateSysctl(container *libcontainer.Config, key string) (string, error) {
+	if strings.Contains(key, "/") || strings.Contains(key, "..") {
+		return "", fmt.Errorf("sysctl %q is not a valid kernel parameter", key)
+	}
+
+	var root string
+	switch {
+	case namespacedSysctls[key]:
+		if !container.Namespaces.Contains(libcontainer.NEWIPC) {
+			return "", fmt.Errorf("sysctl %q requires the %s namespace", key, libcontainer.NEWIPC)
+		}
+		root = "/proc/sys/kernel"
+	case strings.HasPrefix(key, "fs.mqueue."):
+		if !container.Namespaces.Contains(libcontainer.NEWIPC) {
+			return "", fmt.Errorf("sysctl %q requires the %s namespace", key, libcontainer.NEWIPC)
+		}
+		root = "/proc/sys/fs/mqueue"
+	case strings.HasPrefix(key, "net."):
+		if !container.Namespaces.Contains(libcontainer.NEWNET) {
+			return "", fmt.Errorf("sysctl %q requires the %s namespace", key, libcontainer.NEWNET)
+		}
+		root = "/proc/sys/net"
+	default:
+		return "", fmt.Errorf("sysctl %q is not namespaced and cannot be set for a container", key)
+	}
+
+	// the parameter must still be below the namespaced tree once the path is cleaned
+	path := filepath.Join("/proc/sys", strings.Replace(key, ".", "/", -1))
+	if !strings.HasPrefix(path, root+"/") {
+		return "", fmt.Errorf("sysctl %q is not a valid kernel parameter", key)
+	}
+	return path, nil
+}
+
+// setupUts sets the container's hostname and domainname.  They are only set in a new UTS
+// namespace as they would otherwise change the names of the host.
+func setupUts(container *libcontainer.Config) error {
+	if container.Hostname == "" && container.Domainname == "" {
+		return nil
+	}
+
+	if !container.Namespaces.Contains(libcontainer.NEWUTS) {
+		return fmt.Errorf("setting the hostname or domainname requires the %s namespace", libcontainer.NEWUTS)
+	}
+
+	if container.Hostname != "" {
+		if err := syscall.Sethostname([]byte(container.Hostname)); err != nil {
+			return fmt.Errorf("unable to sethostname %q: %s", container.Hostname, err)
+		}
+	}
+
+	if container.Domainname != "" {
+		if err := syscall.Setdomainname([]byte(container.Domainname)); err != nil {
+			return fmt.Errorf("unable to setdomainname %q: %s", container.Domainname, err)
+		}
+	}
+
+	return nil
+}
+
+// setupSysctl writes the container's kernel parameters under /proc/sys.  This has to run
+// before /proc/sys is remounted readonly.
+func setupSysctl(container *libcontainer.Config) error {
+	paths := make(map[string]string, len(container.Sysctl))
+	for key := range container.Sysctl {
+		path, err := validateSysctl(container, key)
+		if err != nil {
+			return err
+		}
+		paths[key] = path
+	}
+
+	for key, value := range container.Sysctl {
+		if err := ioutil.WriteFile(paths[key], []byte(value), 0644); err != nil {
+			return fmt.Errorf("write %s to %s %s", value, key, err)
+		}
+	}
+	return nil
+}
+
+// setupTimeNamespace unshares a new time namespace and writes the container's clock offsets.
+// The time namespace is only entered by the user's process when it is execed so the offsets
+// have to be written before then.
+func setupTimeNamespace(container *libcontainer.Config) error {
+	if !container.Namespaces.Contains(libcontainer.NEWTIME) {
+		if len(container.TimeOffsets) > 0 {
+			return fmt.Errorf("time offsets require the %s namespace", libcontainer.NEWTIME)
+		}
+		return nil
+	}
+
+	if err := syscall.Unshare(cloneNewTime); err != nil {
+		return fmt.Errorf("unshare %s", err)
+	}
+
+	if len(container.TimeOffsets) == 0 {
+		return nil
+	}
+
+	offsets, err := formatTimeOffsets(container.TimeOffsets)
+	if err != nil {
+		return err
+	}
+
+	// all offsets are written at once as they can no longer be changed after a process
+	// has entered the namespace
+	if err := ioutil.WriteFile("/proc/self/timens_offsets", []byte(offsets), 0); err != nil {
+		return fmt.Errorf("write time offsets %s", err)
+	}
+
+	return nil
+}
+
+// formatTimeOffsets returns the offsets in the format of /proc/<pid>/timens_offsets with
+// one line for each clock
+func formatTimeOffsets(timeOffsets map[string]libcontainer.TimeOffset) (string, error) {
+	for clock := range timeOffsets {
+		if clock != "monotonic" && clock != "boottime" {
+			return "", fmt.Errorf("invalid clock %q for time offset", clock)
+		}
+	}
+
+	var offsets []string
+	for _, clock := range []string{"monotonic", "boottime"} {
+		if offset, exists := timeOffsets[clock]; exists {
+			offsets = append(offsets, fmt.Sprintf("%s %d %d\n", clock, offset.Secs, offset.Nanosecs))
+		}
+	}
+
+	return strings.Join(offsets, ""), nil
+}
+
+var schedPolicies = map[string]int{
+	"SCHED_OTHER": 0,
+	"SCHED_FIFO":  1,
+	"SCHED_RR":    2,
+	"SCHED_BATCH": 3,
+	"SCHED_IDLE":  5,
+}
+
+var ioprioClasses = map[string]int{
+	"IOPRIO_CLASS_RT":   1,
+	"IOPRIO_CLASS_BE":   2,
+	"IOPRIO_CLASS_IDLE": 3,
+}
+
+// setupScheduling applies the oom score adjustment, nice value, io priority, scheduling policy
+// and cpu affinity for the container's process.  This has to run before capabilities are dropped
+// as lowering any of these values requires privileges.  The nice value, io priority, policy and
+// affinity are per thread so the caller has to be locked to the thread that execs.
+func setupScheduling(container *libcontainer.Config) error {
+	if container.OomScoreAdj != nil {
+		if err := system.SetOomScoreAdj(*container.OomScoreAdj); err != nil {
+			return fmt.Errorf("set oom score adj %d %s", *container.OomScoreAdj, err)
+		}
+	}
+
+	if container.Nice != nil {
+		if err := system.Setpriority(*container.Nice); err != nil {
+			return fmt.Errorf("set nice %d %s", *container.Nice, err)
+		}
+	}
+
+	if p := container.IOPriority; p != nil {
+		class, exists := ioprioClasses[p.Class]
+		if !exists {
+			return fmt.Errorf("invalid io priority class %q", p.Class)
+		}
+		if p.Priority < 0 || p.Priority > 7 {
+			return fmt.Errorf("invalid io priority %d", p.Priority)
+		}
+		if err := system.IoprioSet(class, p.Priority); err != nil {
+			return fmt.Errorf("set io priority %s %d %s", p.Class, p.Priority, err)
+		}
+	}
+
+	if s := container.Scheduler; s != nil {
+		policy, exists := schedPolicies[s.Policy]
+		if !exists {
+			return fmt.Errorf("invalid scheduling policy %q", s.Policy)
+		}
+		if err := system.SchedSetscheduler(policy, s.Priority); err != nil {
+			return fmt.Errorf("set scheduler %s %d %s", s.Policy, s.Priority, err)
+		}
+	}
+
+	if container.CpuAffinity != "" {
+		cpus, err := parseCpuList(container.CpuAffinity)
+		if err != nil {
+			return err
+		}
+		if err := system.SchedSetaffinity(cpus); err != nil {
+			return fmt.Errorf("set cpu affinity %s %s", container.CpuAffinity, err)
+		}
+	}
+
+	return nil
+}
+
+// parseCpuList parses a list of cpus in the same format as cpuset.cpus, i.e. 0-3,8
+func parseCpuList(list string) ([]int, error) {
+	var cpus []int
+	for _, part := range strings.Split(list, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(part), "-", 2)
+
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid cpu list %q", list)
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, fmt.Errorf("invalid cpu list %q", list)
+			}
+		}
+		if start < 0 || end < start {
+			return nil, fmt.Errorf("invalid cpu list %q", list)
+		}
+
+		for cpu := start; cpu <= end; cpu++ {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
 // FinalizeNamespace drops the caps, sets the correct user
 // and working dir, and closes any leaky file descriptors
 // before execing the command inside the namespace
@@ -316,6 +596,12 @@ func LoadContainerEnvironment(container *libcontainer.Config) error {
 func joinExistingNamespaces(namespaces []libcontainer.Namespace) error {
 	for _, ns := range namespaces {
 		if ns.Path != "" {
+			// setns into a time namespace requires a single threaded process which is
+			// only possible before the Go runtime starts, see the nsenter package
+			if ns.Type == libcontainer.NEWTIME {
+				return fmt.Errorf("joining an existing time namespace is only supported when executing into a running container")
+			}
+
 			f, err := os.OpenFile(ns.Path, os.O_RDONLY, 0)
 			if err != nil {
 				return err
@@ -0,0 +1,84 @@
+// +build linux
+
+package namespaces
+
+import (
+	"testing"
+
+	"github.com/docker/libcontainer"
+)
+
+func TestValidateSysctl(t *testing.T) {
+	container := &libcontainer.Config{
+		Namespaces: libcontainer.Namespaces{
+			{Type: libcontainer.NEWNET},
+			{Type: libcontainer.NEWIPC},
+		},
+	}
+
+	valid := map[string]string{
+		"net.ipv4.ip_forward": "/proc/sys/net/ipv4/ip_forward",
+		"kernel.shmmax":       "/proc/sys/kernel/shmmax",
+		"fs.mqueue.msg_max":   "/proc/sys/fs/mqueue/msg_max",
+	}
+	for key, expected := range valid {
+		path, err := validateSysctl(container, key)
+		if err != nil {
+			t.Fatalf("expected %q to be valid: %s", key, err)
+		}
+		if path != expected {
+			t.Fatalf("expected the path of %q to be %q, got %q", key, expected, path)
+		}
+	}
+
+	invalid := []string{
+		"kernel.core_pattern",
+		"net.core/../../kernel/core_pattern",
+		"net/../kernel/core_pattern",
+		"net...kernel.core_pattern",
+		"fs.mqueue.....kernel.core_pattern",
+		"net.",
+	}
+	for _, key := range invalid {
+		if _, err := validateSysctl(container, key); err == nil {
+			t.Fatalf("expected %q to be rejected", key)
+		}
+	}
+}
+
+func TestValidateSysctlRequiresNamespace(t *testing.T) {
+	container := &libcontainer.Config{}
+
+	for _, key := range []string{"net.ipv4.ip_forward", "kernel.shmmax", "fs.mqueue.msg_max"} {
+		if _, err := validateSysctl(container, key); err == nil {
+			t.Fatalf("expected %q to require a namespace", key)
+		}
+	}
+}
+
+func TestFormatTimeOffsets(t *testing.T) {
+	offsets, err := formatTimeOffsets(map[string]libcontainer.TimeOffset{
+		"boottime":  {Secs: -3600},
+		"monotonic": {Secs: 86400, Nanosecs: 500},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "monotonic 86400 500\nboottime -3600 0\n"; offsets != expected {
+		t.Fatalf("expected offsets %q but received %q", expected, offsets)
+	}
+
+	if _, err := formatTimeOffsets(map[string]libcontainer.TimeOffset{"realtime": {Secs: 1}}); err == nil {
+		t.Fatal("expected an error for an offset of the realtime clock")
+	}
+}
+
+func TestTimeOffsetsRequireNamespace(t *testing.T) {
+	container := &libcontainer.Config{
+		TimeOffsets: map[string]libcontainer.TimeOffset{"monotonic": {Secs: 1}},
+	}
+
+	if err := setupTimeNamespace(container); err == nil {
+		t.Fatal("expected time offsets without a time namespace to be rejected")
+	}
+}
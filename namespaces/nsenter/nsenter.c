@@ -167,7 +167,9 @@ void nsenter()
 		exit(1);
 	}
 
-	char *namespaces[] = { "ipc", "uts", "net", "pid", "mnt" };
+	// time must be joined while the process is still single threaded and before
+	// forking so that the child runs with the container's clock offsets
+	char *namespaces[] = { "ipc", "uts", "net", "pid", "time", "mnt" };
 	const int num = sizeof(namespaces) / sizeof(char *);
 	int i;
 	for (i = 0; i < num; i++) {
@@ -0,0 +1,120 @@
+// +build linux
+
+package namespaces
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"syscall"
+
+	"github.com/docker/libcontainer/system"
+)
+
+// reapOrphans reaps the container's orphaned processes that are reparented to the current
+// process when it is a child subreaper.  The container's init is left to be waited on by its
+// exec.Cmd and, while it is waiting to be reaped, the other processes are reaped by the final
+// pass of the returned function that stops reaping.
+//
+// Any other children of the current process are reaped as well so callers that set the
+// Subreaper option should not start other processes while the container is running.
+func reapOrphans(initPid int) func() {
+	var (
+		sigc = make(chan os.Signal, 10)
+		done = make(chan struct{})
+	)
+	signal.Notify(sigc, syscall.SIGCHLD)
+
+	reap := func() {
+		for {
+			// the exited child is only peeked at so that init is left for its exec.Cmd
+			pid, err := system.PeekExitedChild()
+			if err != nil || pid <= 0 || pid == initPid {
+				return
+			}
+			var ws syscall.WaitStatus
+			if _, err := syscall.Wait4(pid, &ws, syscall.WNOHANG, nil); err != nil {
+				return
+			}
+		}
+	}
+
+	go func() {
+		for {
+			select {
+			case <-sigc:
+				reap()
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigc)
+		close(done)
+		reap()
+	}
+}
+
+// runReapingInit is a minimal init that runs as pid 1 in the container's pid namespace.  It starts
+// the user's process, forwards the signals that it receives to it and reaps any orphaned processes.
+// When the user's process exits the init exits with the same status, killing the rest of the
+// processes in the pid namespace.
+func runReapingInit(args []string, tty bool, pipe *os.File) error {
+	name, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	// as pid 1 we only receive the signals that we have handlers for
+	sigc := make(chan os.Signal, 32)
+	signal.Notify(sigc)
+
+	pid, err := syscall.ForkExec(name, args, &syscall.ProcAttr{
+		Env:   os.Environ(),
+		Files: []uintptr{0, 1, 2},
+		Sys: &syscall.SysProcAttr{
+			// place the process in its own process group so that signals generated by the
+			// terminal are only delivered once
+			Setpgid:    true,
+			Foreground: tty,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	// the user's process has started so let the parent know that the init was successful
+	pipe.Close()
+
+	for sig := range sigc {
+		// the go runtime uses SIGURG to preempt goroutines so it is not forwarded
+		if sig == syscall.SIGURG {
+			continue
+		}
+
+		if sig != syscall.SIGCHLD {
+			syscall.Kill(pid, sig.(syscall.Signal))
+			continue
+		}
+
+		for {
+			var ws syscall.WaitStatus
+			wpid, err := syscall.Wait4(-1, &ws, syscall.WNOHANG, nil)
+			if err != nil || wpid <= 0 {
+				break
+			}
+			if wpid != pid {
+				continue
+			}
+			if ws.Signaled() {
+				os.Exit(EXIT_SIGNAL_OFFSET + int(ws.Signal()))
+			}
+			os.Exit(ws.ExitStatus())
+		}
+	}
+
+	return fmt.Errorf("signal channel closed before process %d exited", pid)
+}
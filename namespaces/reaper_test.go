@@ -0,0 +1,68 @@
+// +build linux
+
+package namespaces
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/docker/libcontainer/system"
+)
+
+// waitForExit waits for the process to exit and returns whether it was reaped
+func waitForExit(pid int, reaped bool) bool {
+	for i := 0; i < 200; i++ {
+		stat, err := system.ReadProcessStat(pid)
+		if err != nil && os.IsNotExist(err) {
+			return true
+		}
+		if err == nil && stat.Field(3) == "Z" && !reaped {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func startOrphan(t *testing.T) int {
+	orphan := exec.Command("true")
+	if err := orphan.Start(); err != nil {
+		t.Fatal(err)
+	}
+	return orphan.Process.Pid
+}
+
+func TestReapOrphansLeavesInit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts and reaps processes")
+	}
+
+	init := exec.Command("sleep", "1")
+	if err := init.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	stop := reapOrphans(init.Process.Pid)
+
+	// an orphan exiting while init is still running is reaped right away
+	orphan := startOrphan(t)
+	if !waitForExit(orphan, true) {
+		t.Fatalf("expected process %d to be reaped", orphan)
+	}
+
+	if err := init.Wait(); err != nil {
+		t.Fatalf("expected init to be left for its exec.Cmd: %s", err)
+	}
+
+	// the final pass reaps the orphans that exited after init
+	orphan = startOrphan(t)
+	if !waitForExit(orphan, false) {
+		t.Fatalf("expected process %d to exit", orphan)
+	}
+	stop()
+	if !waitForExit(orphan, true) {
+		t.Fatalf("expected process %d to be reaped", orphan)
+	}
+}
@@ -9,6 +9,10 @@ import (
 	"github.com/docker/libcontainer"
 )
 
+// cloneNewTime is the CLONE_NEWTIME flag which is not defined in the syscall package.
+// It overlaps with the exit signal bits of clone so it can only be used with unshare and setns.
+const cloneNewTime = 0x80
+
 type initError struct {
 	Message string `json:"message,omitempty"`
 }
@@ -24,6 +28,7 @@ var namespaceInfo = map[libcontainer.NamespaceType]int{
 	libcontainer.NEWIPC:  syscall.CLONE_NEWIPC,
 	libcontainer.NEWUTS:  syscall.CLONE_NEWUTS,
 	libcontainer.NEWPID:  syscall.CLONE_NEWPID,
+	libcontainer.NEWTIME: cloneNewTime,
 }
 
 // New returns a newly initialized Pipe for communication between processes
@@ -39,6 +44,10 @@ func newInitPipe() (parent *os.File, child *os.File, err error) {
 // flags on clone, unshare, and setns
 func GetNamespaceFlags(namespaces libcontainer.Namespaces) (flag int) {
 	for _, v := range namespaces {
+		// the time namespace is unshared by the init process after the clone
+		if v.Type == libcontainer.NEWTIME {
+			continue
+		}
 		flag |= namespaceInfo[v.Type]
 	}
 	return flag
@@ -32,6 +32,12 @@ func ChangeInterfaceName(old, newName string) error {
 	return netlink.NetworkChangeName(iface, newName)
 }
 
+// DeleteInterface deletes the network interface, deleting a veth interface
+// also deletes its peer
+func DeleteInterface(name string) error {
+	return netlink.NetworkLinkDel(name)
+}
+
 func CreateVethPair(name1, name2 string, txQueueLen int) error {
 	return netlink.NetworkCreateVethPair(name1, name2, txQueueLen)
 }
@@ -7,43 +7,150 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"syscall"
 )
 
 const oomCgroupName = "memory"
 
+// The memory pressure level to be notified about.
+type PressureLevel string
+
+const (
+	LowPressure      PressureLevel = "low"
+	MediumPressure   PressureLevel = "medium"
+	CriticalPressure PressureLevel = "critical"
+)
+
+// psiTriggers are the cgroup v2 pressure stall triggers used for each pressure level.
+// Each trigger fires when tasks are stalled on memory for the given number of
+// microseconds within a one second window.
+var psiTriggers = map[PressureLevel]string{
+	LowPressure:      "some 70000 1000000",
+	MediumPressure:   "some 150000 1000000",
+	CriticalPressure: "full 100000 1000000",
+}
+
 // NotifyOnOOM returns channel on which you can expect event about OOM,
 // if process died without OOM this channel will be closed.
 // s is current *libcontainer.State for container.
 func NotifyOnOOM(s *State) (<-chan struct{}, error) {
+	return notifyOnOOM(s, nil)
+}
+
+// notifyOnOOM is NotifyOnOOM with the events stopped and the channel closed once stop is closed
+func notifyOnOOM(s *State, stop <-chan struct{}) (<-chan struct{}, error) {
+	dir, err := memoryCgroupDir(s)
+	if err != nil {
+		return nil, err
+	}
+	return registerMemoryEvent(dir, "memory.oom_control", "", stop)
+}
+
+// NotifyOnMemoryThreshold returns a channel on which an event is sent each time the
+// container's memory usage crosses the threshold in bytes, in either direction.  The
+// channel is closed when the container's memory cgroup is removed.
+func NotifyOnMemoryThreshold(s *State, threshold uint64) (<-chan struct{}, error) {
+	return notifyOnMemoryThreshold(s, threshold, nil)
+}
+
+// notifyOnMemoryThreshold is NotifyOnMemoryThreshold with the events stopped and the channel
+// closed once stop is closed
+func notifyOnMemoryThreshold(s *State, threshold uint64, stop <-chan struct{}) (<-chan struct{}, error) {
+	dir, err := memoryCgroupDir(s)
+	if err != nil {
+		return nil, err
+	}
+	if isUnifiedMemoryCgroup(dir) {
+		return nil, fmt.Errorf("memory usage thresholds are not supported on cgroup v2")
+	}
+	return registerMemoryEvent(dir, "memory.usage_in_bytes", strconv.FormatUint(threshold, 10), stop)
+}
+
+// NotifyOnMemoryPressure returns a channel on which an event is sent each time the
+// container's memory pressure reaches level.  On cgroup v2 the events come from a
+// pressure stall trigger on memory.pressure.  The channel is closed when the
+// container's memory cgroup is removed.
+func NotifyOnMemoryPressure(s *State, level PressureLevel) (<-chan struct{}, error) {
+	trigger, exists := psiTriggers[level]
+	if !exists {
+		return nil, fmt.Errorf("invalid memory pressure level %q", level)
+	}
+	dir, err := memoryCgroupDir(s)
+	if err != nil {
+		return nil, err
+	}
+	if isUnifiedMemoryCgroup(dir) {
+		return registerPressureTrigger(dir, trigger)
+	}
+	return registerMemoryEvent(dir, "memory.pressure_level", string(level), nil)
+}
+
+func memoryCgroupDir(s *State) (string, error) {
 	dir := s.CgroupPaths[oomCgroupName]
 	if dir == "" {
-		return nil, fmt.Errorf("There is no path for %q in state", oomCgroupName)
+		return "", fmt.Errorf("There is no path for %q in state", oomCgroupName)
+	}
+	return dir, nil
+}
+
+// isUnifiedMemoryCgroup returns true if dir is a cgroup v2 directory which has no
+// cgroup.event_control file for eventfd registrations
+func isUnifiedMemoryCgroup(dir string) bool {
+	if _, err := os.Stat(filepath.Join(dir, "cgroup.event_control")); err == nil {
+		return false
 	}
-	oomControl, err := os.Open(filepath.Join(dir, "memory.oom_control"))
+	_, err := os.Stat(filepath.Join(dir, "memory.pressure"))
+	return err == nil
+}
+
+// registerMemoryEvent registers an eventfd for the event file in the cgroup with the
+// optional arguments through cgroup.event_control and sends on the returned channel
+// each time the eventfd is signaled.  The channel is closed and the eventfd released
+// once stop, if it is not nil, is closed.
+func registerMemoryEvent(dir, event, args string, stop <-chan struct{}) (<-chan struct{}, error) {
+	evFile, err := os.Open(filepath.Join(dir, event))
 	if err != nil {
 		return nil, err
 	}
-	fd, _, syserr := syscall.RawSyscall(syscall.SYS_EVENTFD2, 0, syscall.FD_CLOEXEC, 0)
+	// the eventfd is non blocking so that reads from it are interrupted when it is closed
+	fd, _, syserr := syscall.RawSyscall(syscall.SYS_EVENTFD2, 0, syscall.FD_CLOEXEC|syscall.O_NONBLOCK, 0)
 	if syserr != 0 {
+		evFile.Close()
 		return nil, syserr
 	}
 
 	eventfd := os.NewFile(fd, "eventfd")
 
 	eventControlPath := filepath.Join(dir, "cgroup.event_control")
-	data := fmt.Sprintf("%d %d", eventfd.Fd(), oomControl.Fd())
+	data := fmt.Sprintf("%d %d", eventfd.Fd(), evFile.Fd())
+	if args != "" {
+		data += " " + args
+	}
 	if err := ioutil.WriteFile(eventControlPath, []byte(data), 0700); err != nil {
 		eventfd.Close()
-		oomControl.Close()
+		evFile.Close()
 		return nil, err
 	}
-	ch := make(chan struct{})
+	var (
+		ch   = make(chan struct{})
+		done = make(chan struct{})
+	)
+	if stop != nil {
+		go func() {
+			select {
+			case <-stop:
+				eventfd.Close()
+			case <-done:
+			}
+		}()
+	}
 	go func() {
 		defer func() {
+			close(done)
 			close(ch)
 			eventfd.Close()
-			oomControl.Close()
+			evFile.Close()
 		}()
 		buf := make([]byte, 8)
 		for {
@@ -55,6 +162,61 @@ func NotifyOnOOM(s *State) (<-chan struct{}, error) {
 			if _, err := os.Lstat(eventControlPath); os.IsNotExist(err) {
 				return
 			}
+			select {
+			case ch <- struct{}{}:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	return ch, nil
+}
+
+// registerPressureTrigger writes the trigger to the cgroup's memory.pressure file and
+// sends on the returned channel each time the kernel signals it with POLLPRI
+func registerPressureTrigger(dir, trigger string) (<-chan struct{}, error) {
+	fd, err := syscall.Open(filepath.Join(dir, "memory.pressure"), syscall.O_RDWR|syscall.O_CLOEXEC|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := syscall.Write(fd, append([]byte(trigger), 0)); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+	event := &syscall.EpollEvent{Events: syscall.EPOLLPRI, Fd: int32(fd)}
+	if err := syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, fd, event); err != nil {
+		syscall.Close(epfd)
+		syscall.Close(fd)
+		return nil, err
+	}
+	ch := make(chan struct{})
+	go func() {
+		defer func() {
+			close(ch)
+			syscall.Close(epfd)
+			syscall.Close(fd)
+		}()
+		events := make([]syscall.EpollEvent, 1)
+		for {
+			n, err := syscall.EpollWait(epfd, events, -1)
+			if err != nil {
+				if err == syscall.EINTR {
+					continue
+				}
+				return
+			}
+			if n == 0 {
+				continue
+			}
+			// the trigger is signaled with POLLERR once the cgroup is removed
+			if events[0].Events&syscall.EPOLLERR != 0 {
+				return
+			}
 			ch <- struct{}{}
 		}
 	}()
@@ -96,3 +96,44 @@ func TestNotifyOnOOM(t *testing.T) {
 		t.Error("expected event fd to be closed")
 	}
 }
+
+func TestNotifyOnMemoryThreshold(t *testing.T) {
+	memoryPath, err := ioutil.TempDir("", "testnotifythreshold-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(memoryPath)
+	eventPath := filepath.Join(memoryPath, "cgroup.event_control")
+	if err := ioutil.WriteFile(filepath.Join(memoryPath, "memory.usage_in_bytes"), []byte{}, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(eventPath, []byte{}, 0700); err != nil {
+		t.Fatal(err)
+	}
+	st := &State{
+		CgroupPaths: map[string]string{
+			"memory": memoryPath,
+		},
+	}
+	if _, err := NotifyOnMemoryThreshold(st, 1024); err != nil {
+		t.Fatal("expected no error, got:", err)
+	}
+
+	data, err := ioutil.ReadFile(eventPath)
+	if err != nil {
+		t.Fatal("couldn't read event control file:", err)
+	}
+
+	var eventFd, usageFd int
+	var threshold uint64
+	if _, err := fmt.Sscanf(string(data), "%d %d %d", &eventFd, &usageFd, &threshold); err != nil {
+		t.Fatalf("invalid control data %q: %s", data, err)
+	}
+	if threshold != 1024 {
+		t.Fatalf("expected threshold 1024, got %d", threshold)
+	}
+
+	if _, err := NotifyOnMemoryPressure(st, PressureLevel("extreme")); err == nil {
+		t.Fatal("expected an error for an invalid pressure level")
+	}
+}
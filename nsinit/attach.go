@@ -0,0 +1,67 @@
+package main
+
+import (
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/docker/pkg/term"
+)
+
+var attachCommand = cli.Command{
+	Name:   "attach",
+	Usage:  "attach to the stdio of a detached container",
+	Action: attachAction,
+}
+
+func attachAction(context *cli.Context) {
+	container, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conn, _, err := dialMonitor(monitorRequest{Type: "attach"})
+	if err != nil {
+		log.Fatalf("unable to attach: %s", err)
+	}
+	defer conn.Close()
+
+	if container.Tty {
+		state, err := term.SetRawTerminal(os.Stdin.Fd())
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer term.RestoreTerminal(os.Stdin.Fd(), state)
+
+		sigc := make(chan os.Signal, 10)
+		signal.Notify(sigc, syscall.SIGWINCH)
+
+		go func() {
+			resizeMonitorTty()
+
+			for _ = range sigc {
+				resizeMonitorTty()
+			}
+		}()
+	}
+
+	go io.Copy(conn, os.Stdin)
+	io.Copy(os.Stdout, conn)
+}
+
+// resizeMonitorTty resizes the detached container's tty to the size of the current terminal
+func resizeMonitorTty() {
+	ws, err := term.GetWinsize(os.Stdin.Fd())
+	if err != nil {
+		return
+	}
+
+	conn, _, err := dialMonitor(monitorRequest{Type: "resize", Height: ws.Height, Width: ws.Width})
+	if err != nil {
+		return
+	}
+	conn.Close()
+}
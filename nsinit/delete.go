@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/libcontainer"
+)
+
+var deleteCommand = cli.Command{
+	Name:   "delete",
+	Usage:  "delete the state of a container that has exited",
+	Action: deleteAction,
+}
+
+func deleteAction(context *cli.Context) {
+	lock, err := libcontainer.LockState(dataPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	if monitorRunning() {
+		log.Fatal("container is still running")
+	}
+
+	state, err := libcontainer.GetState(dataPath)
+	if err != nil && !os.IsNotExist(err) && !libcontainer.IsDestroyed(err) {
+		log.Fatal(err)
+	}
+
+	if state != nil {
+		if err == nil {
+			log.Fatal("container is still running")
+		}
+
+		if err := libcontainer.DestroyState(dataPath, state); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	if err := libcontainer.DeleteExitStatus(dataPath); err != nil && !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+
+	for _, name := range []string{monitorPidFile, monitorLogFile} {
+		if err := os.Remove(filepath.Join(dataPath, name)); err != nil && !os.IsNotExist(err) {
+			log.Fatal(err)
+		}
+	}
+}
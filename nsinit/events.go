@@ -0,0 +1,50 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/libcontainer"
+)
+
+var eventsCommand = cli.Command{
+	Name:   "events",
+	Usage:  "display the container's events as json lines",
+	Action: eventsAction,
+	Flags: []cli.Flag{
+		cli.StringFlag{Name: "interval", Value: "5s", Usage: "interval between stats events, 0 disables them"},
+		cli.IntFlag{Name: "memory-threshold", Usage: "send an event when the memory usage rises above this number of bytes"},
+	},
+}
+
+func eventsAction(context *cli.Context) {
+	container, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	interval, err := time.ParseDuration(context.String("interval"))
+	if err != nil {
+		log.Fatalf("invalid interval: %s", err)
+	}
+
+	config := &libcontainer.EventConfig{
+		StatsInterval:   interval,
+		MemoryThreshold: uint64(context.Int("memory-threshold")),
+	}
+
+	events, err := libcontainer.NotifyOnEvents(dataPath, container, config, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for e := range events {
+		if err := enc.Encode(e); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
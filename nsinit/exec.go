@@ -7,6 +7,8 @@ import (
 	"os"
 	"os/exec"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"text/tabwriter"
 
@@ -24,6 +26,11 @@ var execCommand = cli.Command{
 	Flags: []cli.Flag{
 		cli.BoolFlag{Name: "list", Usage: "list all registered exec functions"},
 		cli.StringFlag{Name: "func", Value: "exec", Usage: "function name to exec inside a container"},
+		cli.IntFlag{Name: "oom-score-adj", Usage: "oom score adjustment of the process, overriding the container's"},
+		cli.IntFlag{Name: "nice", Usage: "nice value of the process, overriding the container's"},
+		cli.StringFlag{Name: "cpu-affinity", Usage: "list of cpus the process runs on, overriding the container's"},
+		cli.StringFlag{Name: "io-priority", Usage: "io class and priority of the process such as IOPRIO_CLASS_BE:4, overriding the container's"},
+		cli.StringFlag{Name: "scheduler", Usage: "scheduling policy and priority of the process such as SCHED_FIFO:10, overriding the container's"},
 	},
 }
 
@@ -48,15 +55,29 @@ func execAction(context *cli.Context) {
 		log.Fatal(err)
 	}
 
+	lock, err := libcontainer.LockState(dataPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
 	state, err := libcontainer.GetState(dataPath)
 	if err != nil && !os.IsNotExist(err) {
+		if libcontainer.IsDestroyed(err) {
+			log.Fatalf("%s: run delete to remove the stale state", err)
+		}
 		log.Fatalf("unable to read state.json: %s", err)
 	}
 
 	if state != nil {
-		exitCode, err = startInExistingContainer(container, state, context.String("func"), context)
+		lock.Unlock()
+
+		process, err := newProcessConfig(context)
+		if err != nil {
+			log.Fatal(err)
+		}
+		exitCode, err = startInExistingContainer(process.ApplyTo(container), state, context.String("func"), context)
 	} else {
-		exitCode, err = startContainer(container, dataPath, []string(context.Args()))
+		exitCode, err = startContainer(container, dataPath, []string(context.Args()), lock)
 	}
 
 	if err != nil {
@@ -66,6 +87,56 @@ func execAction(context *cli.Context) {
 	os.Exit(exitCode)
 }
 
+// newProcessConfig returns the settings of the process that override the container's
+func newProcessConfig(context *cli.Context) (*libcontainer.ProcessConfig, error) {
+	process := &libcontainer.ProcessConfig{
+		CpuAffinity: context.String("cpu-affinity"),
+	}
+
+	if context.IsSet("oom-score-adj") {
+		oomScoreAdj := context.Int("oom-score-adj")
+		process.OomScoreAdj = &oomScoreAdj
+	}
+
+	if context.IsSet("nice") {
+		nice := context.Int("nice")
+		process.Nice = &nice
+	}
+
+	if raw := context.String("io-priority"); raw != "" {
+		class, priority, err := parsePriority(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid io priority %s", err)
+		}
+		process.IOPriority = &libcontainer.IOPriority{Class: class, Priority: priority}
+	}
+
+	if raw := context.String("scheduler"); raw != "" {
+		policy, priority, err := parsePriority(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid scheduler %s", err)
+		}
+		process.Scheduler = &libcontainer.Scheduler{Policy: policy, Priority: priority}
+	}
+
+	return process, nil
+}
+
+// parsePriority parses a class or policy name followed by an optional priority, such as
+// SCHED_FIFO:10
+func parsePriority(raw string) (string, int, error) {
+	parts := strings.SplitN(raw, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], 0, nil
+	}
+
+	priority, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("%q: %s", raw, err)
+	}
+	return parts[0], priority, nil
+}
+
 // the process for execing a new process inside an existing container is that we have to exec ourself
 // with the nsenter argument so that the C code can setns an the namespaces that we require.  Then that
 // code path will drop us into the path that we can do the final setup of the namespace and exec the users
@@ -126,8 +197,9 @@ func startInExistingContainer(config *libcontainer.Config, state *libcontainer.S
 // startContainer starts the container. Returns the exit status or -1 and an
 // error.
 //
-// Signals sent to the current process will be forwarded to container.
-func startContainer(container *libcontainer.Config, dataPath string, args []string) (int, error) {
+// Signals sent to the current process will be forwarded to container.  The lock
+// on the container's state is released once the container has started.
+func startContainer(container *libcontainer.Config, dataPath string, args []string, lock *libcontainer.StateLock) (int, error) {
 	var (
 		cmd  *exec.Cmd
 		sigc = make(chan os.Signal, 10)
@@ -175,6 +247,8 @@ func startContainer(container *libcontainer.Config, dataPath string, args []stri
 	}
 
 	startCallback := func() {
+		lock.Unlock()
+
 		go func() {
 			resizeTty(master)
 
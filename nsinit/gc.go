@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/libcontainer"
+)
+
+var gcCommand = cli.Command{
+	Name:   "gc",
+	Usage:  "remove the cgroups, network devices and state of containers whose init process is gone",
+	Action: gcAction,
+}
+
+func gcAction(context *cli.Context) {
+	collected, err := libcontainer.GarbageCollect(context.GlobalString("root"), loadConfigFrom)
+	for _, path := range collected {
+		fmt.Println(filepath.Base(path))
+	}
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/namespaces"
+)
+
+var killCommand = cli.Command{
+	Name:   "kill",
+	Usage:  "send a signal, SIGTERM by default, to the container's init or all of its processes",
+	Action: killAction,
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "all", Usage: "send the signal to all of the container's processes"},
+	},
+}
+
+var signals = map[string]syscall.Signal{
+	"ABRT": syscall.SIGABRT,
+	"ALRM": syscall.SIGALRM,
+	"CONT": syscall.SIGCONT,
+	"HUP":  syscall.SIGHUP,
+	"INT":  syscall.SIGINT,
+	"KILL": syscall.SIGKILL,
+	"PIPE": syscall.SIGPIPE,
+	"QUIT": syscall.SIGQUIT,
+	"STOP": syscall.SIGSTOP,
+	"TERM": syscall.SIGTERM,
+	"TSTP": syscall.SIGTSTP,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// parseSignal parses a signal provided as a number or a name with or without the SIG prefix
+func parseSignal(raw string) (syscall.Signal, error) {
+	if n, err := strconv.Atoi(raw); err == nil {
+		return syscall.Signal(n), nil
+	}
+
+	sig, exists := signals[strings.TrimPrefix(strings.ToUpper(raw), "SIG")]
+	if !exists {
+		return -1, fmt.Errorf("unknown signal %q", raw)
+	}
+	return sig, nil
+}
+
+func killAction(context *cli.Context) {
+	sig := syscall.SIGTERM
+	if context.Args().Present() {
+		var err error
+		if sig, err = parseSignal(context.Args().First()); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	container, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// hold the lock so that the container is not deleted or collected while it is signaled
+	lock, err := libcontainer.LockState(dataPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer lock.Unlock()
+
+	state, err := libcontainer.GetState(dataPath)
+	if err != nil {
+		log.Fatalf("unable to read state.json: %s", err)
+	}
+
+	if context.Bool("all") {
+		if _, err := namespaces.SignalAllPids(container, sig); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if err := syscall.Kill(state.InitPid, sig); err != nil {
+		log.Fatal(err)
+	}
+}
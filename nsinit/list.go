@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/libcontainer"
+)
+
+var listCommand = cli.Command{
+	Name:   "list",
+	Usage:  "list the containers in the state root",
+	Action: listAction,
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "json", Usage: "display the containers as json"},
+		cli.BoolFlag{Name: "quiet, q", Usage: "only display the container ids"},
+	},
+}
+
+// containerInfo describes a container in the state root
+type containerInfo struct {
+	ID       string    `json:"id"`
+	InitPid  int       `json:"init_pid,omitempty"`
+	RunState string    `json:"run_state"`
+	Created  time.Time `json:"created,omitempty"`
+	Bundle   string    `json:"bundle"`
+}
+
+func listAction(context *cli.Context) {
+	root := context.GlobalString("root")
+
+	entries, err := ioutil.ReadDir(root)
+	if err != nil && !os.IsNotExist(err) {
+		log.Fatal(err)
+	}
+
+	var containers []*containerInfo
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+
+		info, err := getContainerInfo(filepath.Join(root, e.Name()))
+		if err != nil {
+			// not a container directory
+			if os.IsNotExist(err) {
+				continue
+			}
+			log.Fatal(err)
+		}
+		containers = append(containers, info)
+	}
+
+	switch {
+	case context.Bool("quiet"):
+		for _, c := range containers {
+			fmt.Println(c.ID)
+		}
+	case context.Bool("json"):
+		data, err := json.MarshalIndent(containers, "", "\t")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%s", data)
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 10, 1, 3, ' ', 0)
+		fmt.Fprint(w, "ID\tPID\tSTATUS\tCREATED\tBUNDLE\n")
+
+		for _, c := range containers {
+			created := ""
+			if !c.Created.IsZero() {
+				created = c.Created.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", c.ID, c.InitPid, c.RunState, created, c.Bundle)
+		}
+
+		w.Flush()
+	}
+}
+
+func getContainerInfo(path string) (*containerInfo, error) {
+	container, err := loadConfigFrom(path)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &containerInfo{
+		ID:     filepath.Base(path),
+		Bundle: container.RootFs,
+	}
+
+	state, err := libcontainer.GetState(path)
+	if err != nil && !os.IsNotExist(err) && !libcontainer.IsDestroyed(err) {
+		return nil, err
+	}
+
+	runState, err := libcontainer.GetRunState(state)
+	if err != nil {
+		return nil, err
+	}
+	info.RunState = runState.String()
+
+	if state != nil {
+		info.InitPid = state.InitPid
+		info.Created = state.Created
+	}
+
+	return info, nil
+}
@@ -48,18 +48,30 @@ func main() {
 	app.Flags = []cli.Flag{
 		cli.StringFlag{Name: "nspid"},
 		cli.StringFlag{Name: "console"},
+		cli.StringFlag{Name: "root", Value: "/var/run/nsinit", Usage: "state root containing a directory for each container"},
+		cli.StringFlag{Name: "id", Usage: "id of the container in the state root, overrides the data_path environment variable"},
 	}
 
 	app.Before = preload
 
 	app.Commands = []cli.Command{
+		attachCommand,
 		configCommand,
+		deleteCommand,
+		eventsCommand,
 		execCommand,
+		gcCommand,
 		initCommand,
+		killCommand,
+		listCommand,
+		monitorCommand,
 		oomCommand,
 		pauseCommand,
+		psCommand,
+		startCommand,
 		statsCommand,
 		unpauseCommand,
+		waitCommand,
 	}
 
 	if err := app.Run(os.Args); err != nil {
@@ -0,0 +1,430 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/docker/pkg/term"
+	"github.com/docker/libcontainer"
+	consolepkg "github.com/docker/libcontainer/console"
+	"github.com/docker/libcontainer/namespaces"
+)
+
+const (
+	// the name of the file containing the pid of a detached container's monitor
+	monitorPidFile = "monitor.pid"
+
+	// the name of the unix socket that the monitor serves requests on
+	monitorSocket = "monitor.sock"
+
+	// the name of the file that the monitor's own output and errors are written to
+	monitorLogFile = "monitor.log"
+
+	// how long a write to a client may block before the client is dropped
+	clientWriteTimeout = 5 * time.Second
+)
+
+var monitorCommand = cli.Command{
+	Name:   "monitor",
+	Usage:  "runs the monitor process that owns a detached container",
+	Action: monitorAction,
+}
+
+// monitorStatus is sent by the monitor to the start command once the container
+// has started or failed to start
+type monitorStatus struct {
+	Pid   int    `json:"pid,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// monitorRequest is sent by a client over the monitor's socket.  The type is one of
+// attach, resize, signal, exit-status or events.
+type monitorRequest struct {
+	Type   string `json:"type"`
+	Signal int    `json:"signal,omitempty"`
+	Height uint16 `json:"height,omitempty"`
+	Width  uint16 `json:"width,omitempty"`
+}
+
+// monitorResponse is the monitor's reply to a request.  For attach and events requests the
+// connection is used for the container's output or the event stream after the response.
+type monitorResponse struct {
+	Error      string                   `json:"error,omitempty"`
+	Running    bool                     `json:"running,omitempty"`
+	ExitStatus *libcontainer.ExitStatus `json:"exit_status,omitempty"`
+}
+
+// monitorEvent is streamed to clients that requested events
+type monitorEvent struct {
+	Type       string                   `json:"type"`
+	ExitStatus *libcontainer.ExitStatus `json:"exit_status,omitempty"`
+}
+
+// monitor owns the stdio, or the pty master, of a detached container and serves the
+// requests of clients connected to its socket.
+type monitor struct {
+	sync.Mutex
+
+	cmd    *exec.Cmd
+	master *os.File
+	stdin  io.Writer
+	status *libcontainer.ExitStatus
+
+	// clients attached to the container's output
+	attached map[net.Conn]bool
+
+	// clients receiving events
+	subscribers map[*json.Encoder]net.Conn
+}
+
+// Write copies the container's output to all attached clients, output is discarded while no
+// client is attached.  The clients are written to without holding the monitor's lock so that a stalled client
+// does not block requests, and clients that do not keep up are dropped.
+func (m *monitor) Write(p []byte) (int, error) {
+	m.Lock()
+	conns := make([]net.Conn, 0, len(m.attached))
+	for conn := range m.attached {
+		conns = append(conns, conn)
+	}
+	m.Unlock()
+
+	for _, conn := range conns {
+		conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+		if _, err := conn.Write(p); err != nil {
+			conn.Close()
+
+			m.Lock()
+			delete(m.attached, conn)
+			m.Unlock()
+		}
+	}
+	return len(p), nil
+}
+
+func (m *monitor) publish(e monitorEvent) {
+	m.Lock()
+	subscribers := make(map[*json.Encoder]net.Conn, len(m.subscribers))
+	for enc, conn := range m.subscribers {
+		subscribers[enc] = conn
+	}
+	m.Unlock()
+
+	for enc, conn := range subscribers {
+		conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+		if err := enc.Encode(e); err != nil {
+			conn.Close()
+
+			m.Lock()
+			delete(m.subscribers, enc)
+			m.Unlock()
+		}
+	}
+}
+
+func (m *monitor) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go m.handle(conn)
+	}
+}
+
+func (m *monitor) handle(conn net.Conn) {
+	var (
+		req monitorRequest
+		dec = json.NewDecoder(conn)
+		enc = json.NewEncoder(conn)
+	)
+
+	if err := dec.Decode(&req); err != nil {
+		conn.Close()
+		return
+	}
+
+	// the replies are written without holding the monitor's lock so that a stalled client
+	// does not block the other requests or the container's output
+	m.Lock()
+	cmd, master, status := m.cmd, m.master, m.status
+	m.Unlock()
+
+	conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+
+	reply := func(v interface{}) bool {
+		if err := enc.Encode(v); err != nil {
+			log.Printf("unable to reply to %s request: %s", req.Type, err)
+			conn.Close()
+			return false
+		}
+		return true
+	}
+
+	if cmd == nil || cmd.Process == nil {
+		if reply(monitorResponse{Error: "container has not started"}) {
+			conn.Close()
+		}
+		return
+	}
+
+	var resp monitorResponse
+	switch req.Type {
+	case "attach":
+		if !reply(monitorResponse{Running: status == nil}) {
+			return
+		}
+
+		m.Lock()
+		m.attached[conn] = true
+		m.Unlock()
+
+		// anything buffered by the decoder after the request is input for the container
+		go io.Copy(m.stdin, io.MultiReader(dec.Buffered(), conn))
+		return
+	case "events":
+		if !reply(monitorResponse{Running: status == nil}) {
+			return
+		}
+		if status == nil {
+			// the container may have exited while the response was written, in which case
+			// the exit event was published without this client
+			m.Lock()
+			if status = m.status; status == nil {
+				m.subscribers[enc] = conn
+			}
+			m.Unlock()
+
+			if status == nil {
+				return
+			}
+		}
+		if reply(monitorEvent{Type: "exit", ExitStatus: status}) {
+			conn.Close()
+		}
+		return
+	case "resize":
+		if master == nil {
+			resp.Error = "container does not have a tty"
+		} else if err := term.SetWinsize(master.Fd(), &term.Winsize{Height: req.Height, Width: req.Width}); err != nil {
+			resp.Error = err.Error()
+		}
+	case "signal":
+		if err := cmd.Process.Signal(syscall.Signal(req.Signal)); err != nil {
+			resp.Error = err.Error()
+		}
+	case "exit-status":
+		resp = monitorResponse{Running: status == nil, ExitStatus: status}
+	default:
+		resp.Error = fmt.Sprintf("unknown request type %q", req.Type)
+	}
+	if reply(resp) {
+		conn.Close()
+	}
+}
+
+// monitorAction runs the container for a detached start and records its exit status.  The
+// monitor is the parent of the container's init so the container's state, cgroups and
+// networking are kept until the container exits.
+func monitorAction(context *cli.Context) {
+	syncPipe := os.NewFile(3, "sync")
+	syscall.CloseOnExec(3)
+
+	started := false
+	fail := func(err error) {
+		if !started {
+			json.NewEncoder(syncPipe).Encode(monitorStatus{Error: err.Error()})
+		}
+		log.Fatal(err)
+	}
+
+	container, err := loadConfig()
+	if err != nil {
+		fail(err)
+	}
+
+	m := &monitor{
+		attached:    make(map[net.Conn]bool),
+		subscribers: make(map[*json.Encoder]net.Conn),
+	}
+
+	socketPath := filepath.Join(dataPath, monitorSocket)
+	os.Remove(socketPath)
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		fail(err)
+	}
+	defer os.Remove(socketPath)
+	go m.serve(l)
+
+	var (
+		console string
+		stdin   io.Reader
+		stdout  io.Writer
+		master  *os.File
+		copied  = make(chan struct{})
+		closers []io.Closer
+	)
+
+	if container.Tty {
+		if master, console, err = consolepkg.CreateMasterAndConsole(); err != nil {
+			fail(err)
+		}
+
+		m.Lock()
+		m.master, m.stdin = master, master
+		m.Unlock()
+
+		go func() {
+			io.Copy(m, master)
+			close(copied)
+		}()
+	} else {
+		inr, inw, err := os.Pipe()
+		if err != nil {
+			fail(err)
+		}
+		outr, outw, err := os.Pipe()
+		if err != nil {
+			fail(err)
+		}
+		stdin, stdout, m.stdin = inr, outw, inw
+		closers = append(closers, inr, outw)
+
+		go func() {
+			io.Copy(m, outr)
+			close(copied)
+		}()
+	}
+
+	createCommand := func(container *libcontainer.Config, console, dataPath, init string, pipe *os.File, args []string) *exec.Cmd {
+		cmd := namespaces.DefaultCreateCommand(container, console, dataPath, init, pipe, args)
+		if logPath != "" {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("log=%s", logPath))
+		}
+
+		m.Lock()
+		m.cmd = cmd
+		m.Unlock()
+
+		return cmd
+	}
+
+	startCallback := func() {
+		state, err := libcontainer.GetState(dataPath)
+		if err != nil {
+			fail(err)
+		}
+		json.NewEncoder(syncPipe).Encode(monitorStatus{Pid: state.InitPid})
+		syncPipe.Close()
+		started = true
+	}
+
+	exitCode, err := namespaces.Exec(container, stdin, stdout, stdout, console, dataPath, []string(context.Args()), createCommand, startCallback)
+	if err != nil && !started {
+		fail(err)
+	}
+
+	// close our side of the container's stdio and drain the remaining output so that it is
+	// sent before the exit is published.  The pty master returns EIO once the last process
+	// holding the slave has exited so it is only closed after it is drained.
+	for _, c := range closers {
+		c.Close()
+	}
+	<-copied
+	if master != nil {
+		master.Close()
+	}
+
+	status := &libcontainer.ExitStatus{ExitCode: exitCode}
+	if err != nil {
+		status.Error = err.Error()
+	}
+
+	// the exit status is recorded on disk before being published so that it can be
+	// recovered if the clients go away
+	lock, err := libcontainer.LockState(dataPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := libcontainer.SaveExitStatus(dataPath, status); err != nil {
+		log.Fatal(err)
+	}
+	lock.Unlock()
+
+	m.Lock()
+	m.status = status
+	m.Unlock()
+
+	m.publish(monitorEvent{Type: "exit", ExitStatus: status})
+}
+
+// monitorRunning returns true if the monitor of a detached container is still running
+func monitorRunning() bool {
+	data, err := ioutil.ReadFile(filepath.Join(dataPath, monitorPidFile))
+	if err != nil {
+		return false
+	}
+
+	pid, err := strconv.Atoi(string(data))
+	if err != nil {
+		return false
+	}
+
+	return syscall.Kill(pid, 0) != syscall.ESRCH
+}
+
+// dialMonitor connects to the monitor of a detached container and sends the request
+func dialMonitor(req monitorRequest) (net.Conn, *monitorResponse, error) {
+	conn, err := net.Dial("unix", filepath.Join(dataPath, monitorSocket))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	// the response is read without buffering so that the rest of the connection
+	// can be used for the container's output or events
+	var (
+		resp *monitorResponse
+		line []byte
+		b    = make([]byte, 1)
+	)
+	for {
+		if _, err := conn.Read(b); err != nil {
+			conn.Close()
+			return nil, nil, err
+		}
+		if b[0] == '\n' {
+			break
+		}
+		line = append(line, b[0])
+	}
+
+	if err := json.Unmarshal(line, &resp); err != nil {
+		conn.Close()
+		return nil, nil, err
+	}
+
+	if resp.Error != "" {
+		conn.Close()
+		return nil, nil, errors.New(resp.Error)
+	}
+
+	return conn, resp, nil
+}
@@ -1,9 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/codegangsta/cli"
+	"github.com/docker/libcontainer"
 	"github.com/docker/libcontainer/cgroups"
 	"github.com/docker/libcontainer/cgroups/fs"
 	"github.com/docker/libcontainer/cgroups/systemd"
@@ -39,6 +41,21 @@ func toggle(state cgroups.FreezerState) error {
 		return err
 	}
 
+	if container.Cgroups == nil {
+		return fmt.Errorf("container does not have a cgroups config")
+	}
+
+	// hold the lock so that the container is not deleted or collected while it is toggled
+	lock, err := libcontainer.LockState(dataPath)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	if _, err := libcontainer.GetState(dataPath); err != nil {
+		return fmt.Errorf("unable to read state.json: %s", err)
+	}
+
 	if systemd.UseSystemd() {
 		err = systemd.Freeze(container.Cgroups, state)
 	} else {
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/libcontainer"
+	"github.com/docker/libcontainer/cgroups/fs"
+	"github.com/docker/libcontainer/cgroups/systemd"
+	"github.com/docker/libcontainer/system"
+	"github.com/docker/libcontainer/user"
+)
+
+var psCommand = cli.Command{
+	Name:   "ps",
+	Usage:  "list the processes running inside the container",
+	Action: psAction,
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "json", Usage: "display the processes as json"},
+	},
+}
+
+// processInfo describes a single process running inside the container
+type processInfo struct {
+	// Pid is the process id in the host's pid namespace
+	Pid int `json:"pid"`
+
+	// ContainerPid is the process id in the container's pid namespace, this is the
+	// same as Pid if the container shares the host's pid namespace
+	ContainerPid int `json:"container_pid"`
+
+	Uid     int           `json:"uid"`
+	User    string        `json:"user"`
+	Command string        `json:"command"`
+	CpuTime time.Duration `json:"cpu_time"`
+
+	// Rss is the resident set size of the process in bytes
+	Rss uint64 `json:"rss"`
+}
+
+func psAction(context *cli.Context) {
+	container, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if container.Cgroups == nil {
+		log.Fatal("container does not have a cgroups config")
+	}
+
+	getPids := fs.GetPids
+	if systemd.UseSystemd() {
+		getPids = systemd.GetPids
+	}
+
+	pids, err := getPids(container.Cgroups)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	var processes []*processInfo
+	for _, pid := range pids {
+		p, err := getProcessInfo(container, pid)
+		if err != nil {
+			// the process exited after the pids were read
+			if os.IsNotExist(err) {
+				continue
+			}
+			log.Fatal(err)
+		}
+		processes = append(processes, p)
+	}
+
+	if context.Bool("json") {
+		data, err := json.MarshalIndent(processes, "", "\t")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("%s", data)
+
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 10, 1, 3, ' ', 0)
+	fmt.Fprint(w, "PID\tCONTAINER PID\tUSER\tTIME\tRSS\tCOMMAND\n")
+
+	for _, p := range processes {
+		fmt.Fprintf(w, "%d\t%d\t%s\t%s\t%dK\t%s\n", p.Pid, p.ContainerPid, p.User, p.CpuTime, p.Rss/1024, p.Command)
+	}
+
+	w.Flush()
+}
+
+func getProcessInfo(container *libcontainer.Config, pid int) (*processInfo, error) {
+	var (
+		dir = filepath.Join("/proc", strconv.Itoa(pid))
+		p   = &processInfo{Pid: pid, ContainerPid: pid}
+	)
+
+	if err := parseProcessStatus(dir, p); err != nil {
+		return nil, err
+	}
+
+	cmdline, err := ioutil.ReadFile(filepath.Join(dir, "cmdline"))
+	if err != nil {
+		return nil, err
+	}
+	p.Command = strings.TrimSpace(strings.Replace(string(cmdline), "\x00", " ", -1))
+
+	stat, err := system.ReadProcessStat(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	// utime and stime are the 14th and 15th fields
+	utime, _ := strconv.ParseUint(stat.Field(14), 10, 64)
+	stime, _ := strconv.ParseUint(stat.Field(15), 10, 64)
+	p.CpuTime = time.Duration(utime+stime) * time.Second / time.Duration(system.GetClockTicks())
+
+	if p.Command == "" {
+		// kernel threads and zombies do not have a command line
+		p.Command = "[" + stat.Name + "]"
+	}
+
+	// resolve the user inside the container's rootfs as the container can have
+	// a different set of users than the host
+	p.User = strconv.Itoa(p.Uid)
+	users, err := user.ParsePasswdFileFilter(filepath.Join(container.RootFs, "etc", "passwd"), func(u user.User) bool {
+		return u.Uid == p.Uid
+	})
+	if err == nil && len(users) > 0 {
+		p.User = users[0].Name
+	}
+
+	return p, nil
+}
+
+// parseProcessStatus reads the uid, rss and container pid from /proc/<pid>/status
+func parseProcessStatus(dir string, p *processInfo) error {
+	f, err := os.Open(filepath.Join(dir, "status"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		parts := strings.SplitN(s.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		fields := strings.Fields(parts[1])
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch parts[0] {
+		case "Uid":
+			// the effective uid is the second of the real, effective, saved set and filesystem uids
+			if p.Uid, err = strconv.Atoi(fields[1]); err != nil {
+				return err
+			}
+		case "VmRSS":
+			rss, err := strconv.ParseUint(fields[0], 10, 64)
+			if err != nil {
+				return err
+			}
+			p.Rss = rss * 1024
+		case "NSpid":
+			// the pid in each nested pid namespace starting with the host's, the last
+			// one is the pid in the container's namespace
+			if p.ContainerPid, err = strconv.Atoi(fields[len(fields)-1]); err != nil {
+				return err
+			}
+		}
+	}
+	return s.Err()
+}
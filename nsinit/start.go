@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/libcontainer"
+)
+
+var startCommand = cli.Command{
+	Name:   "start",
+	Usage:  "start a new container",
+	Action: startAction,
+	Flags: []cli.Flag{
+		cli.BoolFlag{Name: "detach", Usage: "run the container in the background after nsinit exits"},
+	},
+}
+
+func startAction(context *cli.Context) {
+	container, err := loadConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	lock, err := libcontainer.LockState(dataPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if _, err := libcontainer.GetState(dataPath); err == nil {
+		log.Fatal("container is already running")
+	} else if libcontainer.IsDestroyed(err) {
+		log.Fatalf("%s: run delete to remove the stale state", err)
+	}
+
+	if _, err := libcontainer.GetExitStatus(dataPath); err == nil {
+		log.Fatal("container has exited, run delete before starting it again")
+	}
+
+	if !context.Bool("detach") {
+		exitCode, err := startContainer(container, dataPath, []string(context.Args()), lock)
+		if err != nil {
+			log.Fatalf("failed to start: %s", err)
+		}
+
+		os.Exit(exitCode)
+	}
+
+	pid, err := startMonitor([]string(context.Args()))
+	if err != nil {
+		log.Fatalf("failed to start: %s", err)
+	}
+	lock.Unlock()
+
+	fmt.Println(pid)
+}
+
+// startMonitor starts the monitor in a new session so that the container keeps running after
+// nsinit exits.  The container's output is copied to any attached clients and the monitor's own
+// output is written to monitor.log so that it does not hold on to nsinit's stdio.  It returns the
+// pid of the container's init once it has started.
+func startMonitor(args []string) (int, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return -1, err
+	}
+	defer r.Close()
+
+	logFile, err := os.OpenFile(filepath.Join(dataPath, monitorLogFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		w.Close()
+		return -1, err
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(os.Args[0], append([]string{"monitor", "--"}, args...)...)
+	cmd.Env = append(os.Environ(), "data_path="+dataPath)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.ExtraFiles = []*os.File{w}
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		w.Close()
+		return -1, err
+	}
+	w.Close()
+
+	if err := ioutil.WriteFile(filepath.Join(dataPath, monitorPidFile), []byte(strconv.Itoa(cmd.Process.Pid)), 0644); err != nil {
+		cmd.Process.Kill()
+		return -1, err
+	}
+
+	var status monitorStatus
+	if err := json.NewDecoder(r).Decode(&status); err != nil {
+		return -1, fmt.Errorf("monitor exited before the container started")
+	}
+	if status.Error != "" {
+		return -1, errors.New(status.Error)
+	}
+
+	return status.Pid, cmd.Process.Release()
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"runtime"
 
 	"github.com/codegangsta/cli"
 	"github.com/docker/libcontainer"
@@ -17,7 +18,12 @@ type rFunc struct {
 }
 
 func loadConfig() (*libcontainer.Config, error) {
-	f, err := os.Open(filepath.Join(dataPath, "container.json"))
+	return loadConfigFrom(dataPath)
+}
+
+// loadConfigFrom loads the container.json in the container's directory
+func loadConfigFrom(path string) (*libcontainer.Config, error) {
+	f, err := os.Open(filepath.Join(path, "container.json"))
 	if err != nil {
 		return nil, err
 	}
@@ -69,6 +75,10 @@ func loadConfigFromFd() (*libcontainer.Config, error) {
 }
 
 func preload(context *cli.Context) error {
+	if id := context.GlobalString("id"); id != "" {
+		dataPath = filepath.Join(context.GlobalString("root"), id)
+	}
+
 	if logPath != "" {
 		if err := openLog(logPath); err != nil {
 			return err
@@ -79,6 +89,10 @@ func preload(context *cli.Context) error {
 }
 
 func runFunc(f *rFunc) {
+	// scheduling attributes are per thread so make sure that the thread
+	// applying them is the one that execs the user's process
+	runtime.LockOSThread()
+
 	userArgs := findUserArgs()
 
 	config, err := loadConfigFromFd()
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/codegangsta/cli"
+	"github.com/docker/libcontainer"
+)
+
+var waitCommand = cli.Command{
+	Name:   "wait",
+	Usage:  "wait for a detached container to exit and exit with its exit code",
+	Action: waitAction,
+}
+
+func waitAction(context *cli.Context) {
+	for {
+		running := monitorRunning()
+
+		status, err := libcontainer.GetExitStatus(dataPath)
+		if err == nil {
+			if status.Error != "" {
+				log.Printf("container exited with error: %s", status.Error)
+			}
+			os.Exit(status.ExitCode)
+		}
+
+		if !os.IsNotExist(err) {
+			log.Fatal(err)
+		}
+
+		// the exit status is written before the monitor exits so if the monitor was not
+		// running before the exit status was checked it will never be written
+		if !running {
+			log.Fatal("container is not running")
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
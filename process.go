@@ -24,4 +24,45 @@ type ProcessConfig struct {
 	Stdin  io.ReadCloser
 	Stdout io.WriteCloser
 	Stderr io.WriteCloser
+
+	// OOM score adjustment of the process, nil to inherit the container's setting.
+	OomScoreAdj *int
+
+	// Nice value of the process, nil to inherit the container's setting.
+	Nice *int
+
+	// I/O scheduling class and priority of the process, nil to inherit the container's setting.
+	IOPriority *IOPriority
+
+	// CPU scheduling policy and priority of the process, nil to inherit the container's setting.
+	Scheduler *Scheduler
+
+	// List of CPUs the process is allowed to run on, such as 0-3,8, empty to inherit the
+	// container's setting.
+	CpuAffinity string
+}
+
+// ApplyTo returns a copy of the container's config with the scheduling settings of the
+// process overriding the container's.  The copy is used by FinalizeSetns when the process
+// joins the running container.
+func (p *ProcessConfig) ApplyTo(container *Config) *Config {
+	config := *container
+
+	if p.OomScoreAdj != nil {
+		config.OomScoreAdj = p.OomScoreAdj
+	}
+	if p.Nice != nil {
+		config.Nice = p.Nice
+	}
+	if p.IOPriority != nil {
+		config.IOPriority = p.IOPriority
+	}
+	if p.Scheduler != nil {
+		config.Scheduler = p.Scheduler
+	}
+	if p.CpuAffinity != "" {
+		config.CpuAffinity = p.CpuAffinity
+	}
+
+	return &config
 }
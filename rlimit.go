@@ -0,0 +1,76 @@
+package libcontainer
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// RlimitType is the resource that an Rlimit applies to.  In the json configuration it
+// can be specified as the symbolic name, such as RLIMIT_NOFILE, or as the raw value.
+type RlimitType int
+
+var rlimitNames = map[string]RlimitType{
+	"RLIMIT_CPU":        0,
+	"RLIMIT_FSIZE":      1,
+	"RLIMIT_DATA":       2,
+	"RLIMIT_STACK":      3,
+	"RLIMIT_CORE":       4,
+	"RLIMIT_RSS":        5,
+	"RLIMIT_NPROC":      6,
+	"RLIMIT_NOFILE":     7,
+	"RLIMIT_MEMLOCK":    8,
+	"RLIMIT_AS":         9,
+	"RLIMIT_LOCKS":      10,
+	"RLIMIT_SIGPENDING": 11,
+	"RLIMIT_MSGQUEUE":   12,
+	"RLIMIT_NICE":       13,
+	"RLIMIT_RTPRIO":     14,
+	"RLIMIT_RTTIME":     15,
+}
+
+// ParseRlimitType returns the RlimitType for the symbolic name
+func ParseRlimitType(name string) (RlimitType, error) {
+	t, exists := rlimitNames[name]
+	if !exists {
+		return -1, fmt.Errorf("unknown rlimit type %q", name)
+	}
+	return t, nil
+}
+
+func (t RlimitType) String() string {
+	for name, v := range rlimitNames {
+		if v == t {
+			return name
+		}
+	}
+	return fmt.Sprintf("%d", int(t))
+}
+
+func (t RlimitType) MarshalJSON() ([]byte, error) {
+	for name, v := range rlimitNames {
+		if v == t {
+			return json.Marshal(name)
+		}
+	}
+	return json.Marshal(int(t))
+}
+
+func (t *RlimitType) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		// not a string so fallback to the raw value
+		var v int
+		if err := json.Unmarshal(data, &v); err != nil {
+			return err
+		}
+		*t = RlimitType(v)
+		return nil
+	}
+
+	v, err := ParseRlimitType(name)
+	if err != nil {
+		return err
+	}
+	*t = v
+	return nil
+}
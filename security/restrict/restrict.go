@@ -11,6 +11,21 @@ import (
 
 const defaultMountFlags = syscall.MS_NOEXEC | syscall.MS_NOSUID | syscall.MS_NODEV
 
+// The paths that are made readonly when a container restricts /proc and /sys without
+// configuring its own list
+var DefaultReadonlyPaths = []string{
+	"/proc/sys",
+	"/proc/sysrq-trigger",
+	"/proc/irq",
+	"/proc/bus",
+}
+
+// The paths that are masked when a container restricts /proc and /sys without
+// configuring its own list
+var DefaultMaskedPaths = []string{
+	"/proc/kcore",
+}
+
 func mountReadonly(path string) error {
 	for i := 0; i < 5; i++ {
 		if err := syscall.Mount("", path, "", syscall.MS_REMOUNT|syscall.MS_RDONLY, ""); err != nil && !os.IsNotExist(err) {
@@ -38,16 +53,36 @@ func mountReadonly(path string) error {
 
 // This has to be called while the container still has CAP_SYS_ADMIN (to be able to perform mounts).
 // However, afterwards, CAP_SYS_ADMIN should be dropped (otherwise the user will be able to revert those changes).
-func Restrict(mounts ...string) error {
-	for _, dest := range mounts {
+func Restrict(readonlyPaths, maskedPaths []string) error {
+	for _, dest := range readonlyPaths {
 		if err := mountReadonly(dest); err != nil {
 			return fmt.Errorf("unable to remount %s readonly: %s", dest, err)
 		}
 	}
 
-	if err := syscall.Mount("/dev/null", "/proc/kcore", "", syscall.MS_BIND, ""); err != nil && !os.IsNotExist(err) {
-		return fmt.Errorf("unable to bind-mount /dev/null over /proc/kcore: %s", err)
+	for _, dest := range maskedPaths {
+		if err := maskPath(dest); err != nil {
+			return fmt.Errorf("unable to mask %s: %s", dest, err)
+		}
 	}
 
 	return nil
 }
+
+// maskPath bind mounts /dev/null over a file or mounts an empty readonly tmpfs over a
+// directory so that its contents can not be read
+func maskPath(path string) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if stat.IsDir() {
+		return syscall.Mount("tmpfs", path, "tmpfs", syscall.MS_RDONLY|defaultMountFlags, "")
+	}
+
+	return syscall.Mount("/dev/null", path, "", syscall.MS_BIND, "")
+}
@@ -4,6 +4,6 @@ package restrict
 
 import "fmt"
 
-func Restrict() error {
+func Restrict(readonlyPaths, maskedPaths []string) error {
 	return fmt.Errorf("not supported")
 }
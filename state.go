@@ -2,14 +2,26 @@ package libcontainer
 
 import (
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/docker/libcontainer/network"
+	"github.com/docker/libcontainer/system"
 )
 
+// The version of the state.json format written by SaveState.  State files written
+// before the format was versioned have a version of 0.
+const StateVersion = 2
+
 // State represents a running container's state
 type State struct {
+	// Version is the version of the state.json format
+	Version int `json:"version"`
+
 	// InitPid is the init process id in the parent namespace
 	InitPid int `json:"init_pid,omitempty"`
 
@@ -21,11 +33,27 @@ type State struct {
 
 	// Path to all the cgroups setup for a container. Key is cgroup subsystem name.
 	CgroupPaths map[string]string `json:"cgroup_paths,omitempty"`
+
+	// Created is the time that the container was started
+	Created time.Time `json:"created,omitempty"`
+}
+
+// ExitStatus records how a detached container's init process exited so that it
+// can be collected after the process that started the container is gone
+type ExitStatus struct {
+	// ExitCode is the exit code of the init process, or 128 plus the signal that killed it
+	ExitCode int `json:"exit_code"`
+
+	// Error is set when the container failed to start or could not be waited on
+	Error string `json:"error,omitempty"`
 }
 
 // The running state of the container.
 type RunState int
 
+// The name of the file recording the exit status of a detached container
+const exitStatusFile = "exit.json"
+
 const (
 	// The name of the runtime state file
 	stateFile = "state.json"
@@ -43,19 +71,103 @@ const (
 	Destroyed
 )
 
+func (s RunState) String() string {
+	switch s {
+	case Running:
+		return "running"
+	case Pausing:
+		return "pausing"
+	case Paused:
+		return "paused"
+	case Destroyed:
+		return "destroyed"
+	}
+	return "unknown"
+}
+
+// GetRunState derives the run state of a container from its saved state.  The container is
+// destroyed if the init process no longer exists or the pid has been reused by another
+// process, otherwise the run state is read from the container's freezer cgroup.
+func GetRunState(state *State) (RunState, error) {
+	if state == nil {
+		return Destroyed, nil
+	}
+
+	started, err := system.GetProcessStartTime(state.InitPid)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Destroyed, nil
+		}
+		return Destroyed, err
+	}
+
+	if started != state.InitStartTime {
+		return Destroyed, nil
+	}
+
+	if dir := state.CgroupPaths["freezer"]; dir != "" {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "freezer.state"))
+		if err != nil && !os.IsNotExist(err) {
+			return Destroyed, err
+		}
+
+		switch strings.TrimSpace(string(data)) {
+		case "FREEZING":
+			return Pausing, nil
+		case "FROZEN":
+			return Paused, nil
+		}
+	}
+
+	return Running, nil
+}
+
 // SaveState writes the container's runtime state to a state.json file
 // in the specified path
 func SaveState(basePath string, state *State) error {
-	f, err := os.Create(filepath.Join(basePath, stateFile))
+	state.Version = StateVersion
+
+	return writeJSON(filepath.Join(basePath, stateFile), state)
+}
+
+// writeJSON atomically replaces the file at path with the json encoding of v by
+// writing it to a temporary file in the same directory and renaming it over path
+func writeJSON(path string, v interface{}) error {
+	f, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path))
 	if err != nil {
 		return err
 	}
-	defer f.Close()
 
-	return json.NewEncoder(f).Encode(state)
+	if err := json.NewEncoder(f).Encode(v); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	if err := os.Chmod(f.Name(), 0644); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
 }
 
 // GetState reads the state.json file for a running container
+//
+// If the container's init process no longer exists, or its pid has been reused by
+// another process, the state is returned along with a ContainerDestroyed error so that
+// the container's resources can still be cleaned up.
 func GetState(basePath string) (*State, error) {
 	f, err := os.Open(filepath.Join(basePath, stateFile))
 	if err != nil {
@@ -68,10 +180,100 @@ func GetState(basePath string) (*State, error) {
 		return nil, err
 	}
 
+	if err := migrateState(state); err != nil {
+		return nil, err
+	}
+
+	runState, err := GetRunState(state)
+	if err != nil {
+		return nil, err
+	}
+
+	if runState == Destroyed {
+		return state, newGenericError(fmt.Errorf("container init process %d with start time %s no longer exists", state.InitPid, state.InitStartTime), ContainerDestroyed)
+	}
+
 	return state, nil
 }
 
+// migrateState upgrades a state read from an older state.json to the current format
+func migrateState(state *State) error {
+	if state.Version > StateVersion {
+		return fmt.Errorf("state version %d is newer than the supported version %d", state.Version, StateVersion)
+	}
+
+	if state.Version < 1 {
+		// unversioned state files may be missing the network state and cgroup paths.
+		// A missing network state decodes to its zero value which means that there are
+		// no host side network devices to clean up.
+		if state.CgroupPaths == nil {
+			state.CgroupPaths = make(map[string]string)
+		}
+	}
+
+	if state.Version < 2 {
+		// state files before version 2 recorded the start time of an init process with
+		// spaces in its command name from the wrong field of its stat
+		if started, err := system.GetProcessStartTime(state.InitPid); err == nil {
+			if legacy, err := legacyProcessStartTime(state.InitPid); err == nil && legacy != started && legacy == state.InitStartTime {
+				state.InitStartTime = started
+			}
+		}
+	}
+
+	state.Version = StateVersion
+
+	return nil
+}
+
+// legacyProcessStartTime returns the start time of the process as it was read before version 2
+// of the state format, by splitting its stat on spaces without parsing the command name
+func legacyProcessStartTime(pid int) (string, error) {
+	stat, err := system.ReadProcessStat(pid)
+	if err != nil {
+		return "", err
+	}
+
+	// each space in the command name shifted the fields after it by one
+	return stat.Field(22 - strings.Count(stat.Name, " ")), nil
+}
+
+// IsDestroyed returns true if the error is a ContainerDestroyed error
+func IsDestroyed(err error) bool {
+	if e, ok := err.(Error); ok {
+		return e.Code() == ContainerDestroyed
+	}
+	return false
+}
+
 // DeleteState deletes the state.json file
 func DeleteState(basePath string) error {
 	return os.Remove(filepath.Join(basePath, stateFile))
 }
+
+// SaveExitStatus writes the container's exit status to an exit.json file
+// in the specified path
+func SaveExitStatus(basePath string, status *ExitStatus) error {
+	return writeJSON(filepath.Join(basePath, exitStatusFile), status)
+}
+
+// GetExitStatus reads the exit.json file for a detached container that has exited
+func GetExitStatus(basePath string) (*ExitStatus, error) {
+	f, err := os.Open(filepath.Join(basePath, exitStatusFile))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var status *ExitStatus
+	if err := json.NewDecoder(f).Decode(&status); err != nil {
+		return nil, err
+	}
+
+	return status, nil
+}
+
+// DeleteExitStatus deletes the exit.json file
+func DeleteExitStatus(basePath string) error {
+	return os.Remove(filepath.Join(basePath, exitStatusFile))
+}
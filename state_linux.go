@@ -0,0 +1,49 @@
+// +build linux
+
+package libcontainer
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// The name of the file used to serialize operations on a container's state
+const lockFile = "state.lock"
+
+// StateLock is an exclusive lock on a container's state directory
+type StateLock struct {
+	f *os.File
+}
+
+// LockState takes an exclusive lock on the container's state in the specified path,
+// blocking until any other holder releases it.  Operations that change the container's
+// state should hold the lock so that concurrent callers do not race on the same path.
+func LockState(basePath string) (*StateLock, error) {
+	return lockState(basePath, os.O_CREATE)
+}
+
+// lockExistingState is LockState for a path whose lock file is not created if it does not
+// exist, it returns an error for which os.IsNotExist is true instead
+func lockExistingState(basePath string) (*StateLock, error) {
+	return lockState(basePath, 0)
+}
+
+func lockState(basePath string, flag int) (*StateLock, error) {
+	f, err := os.OpenFile(filepath.Join(basePath, lockFile), flag|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &StateLock{f: f}, nil
+}
+
+// Unlock releases the lock
+func (l *StateLock) Unlock() error {
+	return l.f.Close()
+}
@@ -0,0 +1,127 @@
+// +build linux
+
+package libcontainer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/libcontainer/system"
+)
+
+func TestGetStateDetectsStaleState(t *testing.T) {
+	root, err := ioutil.TempDir("", "libcontainer-state-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	started, err := system.GetProcessStartTime(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := SaveState(root, &State{InitPid: os.Getpid(), InitStartTime: started}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := GetState(root); err != nil {
+		t.Fatalf("expected a running container, got: %s", err)
+	}
+
+	// the pid is still alive but it was reused by a process started at another time
+	if err := SaveState(root, &State{InitPid: os.Getpid(), InitStartTime: "1"}); err != nil {
+		t.Fatal(err)
+	}
+	state, err := GetState(root)
+	if !IsDestroyed(err) {
+		t.Fatalf("expected a ContainerDestroyed error, got: %v", err)
+	}
+	if state == nil || state.InitPid != os.Getpid() {
+		t.Fatal("expected the stale state to be returned with the error")
+	}
+}
+
+func TestGetStateMigratesUnversionedState(t *testing.T) {
+	root, err := ioutil.TempDir("", "libcontainer-state-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	started, err := system.GetProcessStartTime(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := fmt.Sprintf(`{"init_pid":%d,"init_start_time":%q}`, os.Getpid(), started)
+	if err := ioutil.WriteFile(filepath.Join(root, stateFile), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := GetState(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Version != StateVersion {
+		t.Fatalf("expected state version %d, got %d", StateVersion, state.Version)
+	}
+	if state.CgroupPaths == nil {
+		t.Fatal("expected cgroup paths to be initialized")
+	}
+}
+
+func TestGetStateMigratesLegacyStartTime(t *testing.T) {
+	root, err := ioutil.TempDir("", "libcontainer-state-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+
+	sleep, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	// the command name of the process is the name of the symlink that it is executed through
+	name := filepath.Join(root, "init with spaces")
+	if err := os.Symlink(sleep, name); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(name, "10")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		cmd.Process.Kill()
+		cmd.Wait()
+	}()
+
+	legacy, err := legacyProcessStartTime(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	started, err := system.GetProcessStartTime(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if legacy == started {
+		t.Fatalf("expected the legacy start time to differ from %s", started)
+	}
+
+	data := fmt.Sprintf(`{"version":1,"init_pid":%d,"init_start_time":%q}`, cmd.Process.Pid, legacy)
+	if err := ioutil.WriteFile(filepath.Join(root, stateFile), []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := GetState(root)
+	if err != nil {
+		t.Fatalf("expected a running container, got: %s", err)
+	}
+	if state.InitStartTime != started {
+		t.Fatalf("expected the start time to be migrated to %s, got %s", started, state.InitStartTime)
+	}
+}
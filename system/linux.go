@@ -52,6 +52,39 @@ func ClearKeepCaps() error {
 	return nil
 }
 
+// PR_SET_CHILD_SUBREAPER is not defined in the syscall package
+const prSetChildSubreaper = 36
+
+// SetSubreaper marks the current process as a child subreaper so that orphaned
+// descendants are reparented to it instead of the init process
+func SetSubreaper(i int) error {
+	if _, _, err := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, uintptr(i), 0); err != 0 {
+		return err
+	}
+	return nil
+}
+
+// waitid flags that are not defined in the syscall package
+const (
+	pAll    = 0
+	wNowait = 0x1000000
+)
+
+// PeekExitedChild returns the pid of a child of the current process that has exited without
+// reaping it, or 0 when no child has exited
+func PeekExitedChild() (int, error) {
+	// the pid follows the signo, errno and code ints of the siginfo, aligned to the size of a
+	// pointer because of the pointers in the union that it belongs to
+	var (
+		info   [128]byte
+		offset = (3*4 + unsafe.Sizeof(uintptr(0)) - 1) &^ (unsafe.Sizeof(uintptr(0)) - 1)
+	)
+	if _, _, err := syscall.Syscall6(syscall.SYS_WAITID, pAll, 0, uintptr(unsafe.Pointer(&info[0])), syscall.WEXITED|syscall.WNOHANG|wNowait, 0, 0); err != 0 {
+		return 0, err
+	}
+	return int(*(*int32)(unsafe.Pointer(&info[offset]))), nil
+}
+
 func Setctty() error {
 	if _, _, err := syscall.RawSyscall(syscall.SYS_IOCTL, 0, uintptr(syscall.TIOCSCTTY), 0); err != 0 {
 		return err
@@ -0,0 +1,39 @@
+// +build linux
+
+package system
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// mount_setattr has the same number on all architectures
+const sysMountSetattr = 442
+
+const (
+	AT_RECURSIVE      = 0x8000
+	MOUNT_ATTR_RDONLY = 0x1
+)
+
+// MountAttr is the mount_attr structure passed to mount_setattr
+type MountAttr struct {
+	AttrSet     uint64
+	AttrClr     uint64
+	Propagation uint64
+	UsernsFd    uint64
+}
+
+// MountSetattr changes the attributes of the mount at path, and of all of its
+// submounts when flags contains AT_RECURSIVE.  ENOSYS is returned by kernels
+// older than 5.12.
+func MountSetattr(path string, flags int, attr *MountAttr) error {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return err
+	}
+	atFdcwd := -100
+	if _, _, err := syscall.Syscall6(sysMountSetattr, uintptr(atFdcwd), uintptr(unsafe.Pointer(p)), uintptr(flags), uintptr(unsafe.Pointer(attr)), unsafe.Sizeof(*attr), 0); err != 0 {
+		return err
+	}
+	return nil
+}
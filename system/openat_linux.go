@@ -0,0 +1,60 @@
+// +build linux
+
+package system
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// openat2 has the same number on all architectures
+const sysOpenat2 = 437
+
+const (
+	O_PATH = 0x200000
+
+	AT_SYMLINK_NOFOLLOW = 0x100
+
+	RESOLVE_NO_MAGICLINKS = 0x02
+	RESOLVE_IN_ROOT       = 0x10
+)
+
+// OpenHow is the open_how structure passed to openat2
+type OpenHow struct {
+	Flags   uint64
+	Mode    uint64
+	Resolve uint64
+}
+
+// Openat2 opens path relative to the directory dirfd with the resolution restrictions
+// in how.  ENOSYS is returned by kernels older than 5.6.
+func Openat2(dirfd int, path string, how *OpenHow) (int, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return -1, err
+	}
+	fd, _, errno := syscall.Syscall6(sysOpenat2, uintptr(dirfd), uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(how)), unsafe.Sizeof(*how), 0, 0)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
+
+// Readlinkat reads the target of the symlink at path relative to dirfd.  An empty path
+// reads the symlink that dirfd was opened on with O_PATH|O_NOFOLLOW.
+func Readlinkat(dirfd int, path string) (string, error) {
+	p, err := syscall.BytePtrFromString(path)
+	if err != nil {
+		return "", err
+	}
+	for size := 128; ; size *= 2 {
+		buf := make([]byte, size)
+		n, _, errno := syscall.Syscall6(syscall.SYS_READLINKAT, uintptr(dirfd), uintptr(unsafe.Pointer(p)), uintptr(unsafe.Pointer(&buf[0])), uintptr(size), 0, 0)
+		if errno != 0 {
+			return "", errno
+		}
+		if int(n) < size {
+			return string(buf[:n]), nil
+		}
+	}
+}
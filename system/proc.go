@@ -1,21 +1,60 @@
 package system
 
 import (
+	"fmt"
 	"io/ioutil"
 	"path/filepath"
 	"strconv"
 	"strings"
 )
 
+// ProcessStat is the contents of /proc/<pid>/stat
+type ProcessStat struct {
+	// Name is the command name of the process without the parentheses
+	Name string
+
+	// fields holds the fields after the command name, starting with the state
+	fields []string
+}
+
+// Field returns the field at pos, numbered as in proc(5) starting with the pid at 1,
+// or an empty string if the process does not have the field
+func (s *ProcessStat) Field(pos int) string {
+	// the pid and command name are not part of fields
+	if pos < 3 || pos-3 >= len(s.fields) {
+		return ""
+	}
+	return s.fields[pos-3]
+}
+
+// ReadProcessStat reads and parses /proc/<pid>/stat.  The command name can contain spaces
+// and parentheses so the fields are parsed after its last closing parenthesis.
+func ReadProcessStat(pid int) (*ProcessStat, error) {
+	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	stat := string(data)
+	start, end := strings.Index(stat, "("), strings.LastIndex(stat, ")")
+	if start == -1 || end < start {
+		return nil, fmt.Errorf("invalid stat data for pid %d", pid)
+	}
+
+	return &ProcessStat{
+		Name:   stat[start+1 : end],
+		fields: strings.Fields(stat[end+1:]),
+	}, nil
+}
+
 // look in /proc to find the process start time so that we can verify
 // that this pid has started after ourself
 func GetProcessStartTime(pid int) (string, error) {
-	data, err := ioutil.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	stat, err := ReadProcessStat(pid)
 	if err != nil {
 		return "", err
 	}
 
-	parts := strings.Split(string(data), " ")
 	// the starttime is located at pos 22
 	// from the man page
 	//
@@ -23,5 +62,9 @@ func GetProcessStartTime(pid int) (string, error) {
 	// (22)  The  time the process started after system boot.  In kernels before Linux 2.6, this
 	// value was expressed in jiffies.  Since Linux 2.6, the value is expressed in  clock  ticks
 	// (divide by sysconf(_SC_CLK_TCK)).
-	return parts[22-1], nil // starts at 1
+	started := stat.Field(22)
+	if started == "" {
+		return "", fmt.Errorf("invalid stat data for pid %d", pid)
+	}
+	return started, nil
 }
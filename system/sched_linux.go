@@ -0,0 +1,63 @@
+// +build linux
+
+package system
+
+import (
+	"io/ioutil"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+const ioprioWhoProcess = 1
+
+// ioprioClassShift is the number of bits the class is shifted by in the ioprio value
+const ioprioClassShift = 13
+
+// SetOomScoreAdj sets the OOM killer score adjustment of the current process
+func SetOomScoreAdj(score int) error {
+	return ioutil.WriteFile("/proc/self/oom_score_adj", []byte(strconv.Itoa(score)), 0644)
+}
+
+// Setpriority sets the nice value of the calling thread
+func Setpriority(nice int) error {
+	return syscall.Setpriority(syscall.PRIO_PROCESS, 0, nice)
+}
+
+// IoprioSet sets the I/O scheduling class and priority of the calling thread
+func IoprioSet(class, priority int) error {
+	ioprio := class<<ioprioClassShift | priority
+	if _, _, err := syscall.RawSyscall(syscall.SYS_IOPRIO_SET, ioprioWhoProcess, 0, uintptr(ioprio)); err != 0 {
+		return err
+	}
+	return nil
+}
+
+// SchedSetscheduler sets the CPU scheduling policy and priority of the calling thread
+func SchedSetscheduler(policy, priority int) error {
+	param := struct{ priority int32 }{int32(priority)}
+	if _, _, err := syscall.RawSyscall(syscall.SYS_SCHED_SETSCHEDULER, 0, uintptr(policy), uintptr(unsafe.Pointer(&param))); err != 0 {
+		return err
+	}
+	return nil
+}
+
+// SchedSetaffinity restricts the calling thread to the provided cpus
+func SchedSetaffinity(cpus []int) error {
+	var max int
+	for _, cpu := range cpus {
+		if cpu > max {
+			max = cpu
+		}
+	}
+
+	mask := make([]uint64, max/64+1)
+	for _, cpu := range cpus {
+		mask[cpu/64] |= 1 << uint(cpu%64)
+	}
+
+	if _, _, err := syscall.RawSyscall(syscall.SYS_SCHED_SETAFFINITY, 0, uintptr(len(mask)*8), uintptr(unsafe.Pointer(&mask[0]))); err != 0 {
+		return err
+	}
+	return nil
+}
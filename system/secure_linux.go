@@ -0,0 +1,191 @@
+// +build linux
+
+package system
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// the maximum number of symlinks followed when resolving a path, matching the kernel
+const maxSymlinkDepth = 40
+
+// WithProcfd opens the path inside the rootfs without following any symlink out of the
+// rootfs and calls fn with a /proc/self/fd path for it.  Mounting through the fd means
+// that a symlink swapped into the rootfs after the path was resolved can not redirect
+// the mount onto the host.
+func WithProcfd(rootfs, path string, fn func(procfd string) error) error {
+	f, err := OpenInRoot(rootfs, path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return fn(fmt.Sprintf("/proc/self/fd/%d", f.Fd()))
+}
+
+// OpenInRoot opens the path with O_PATH, resolving symlinks and .. as if the rootfs
+// were the root directory.  openat2 with RESOLVE_IN_ROOT is used where available,
+// falling back to walking the path one component at a time.
+func OpenInRoot(rootfs, path string) (*os.File, error) {
+	rootFd, err := syscall.Open(rootfs, O_PATH|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: rootfs, Err: err}
+	}
+	defer syscall.Close(rootFd)
+
+	fd, err := Openat2(rootFd, path, &OpenHow{
+		Flags:   O_PATH | syscall.O_CLOEXEC,
+		Resolve: RESOLVE_IN_ROOT | RESOLVE_NO_MAGICLINKS,
+	})
+	if err == nil {
+		return os.NewFile(uintptr(fd), filepath.Join(rootfs, path)), nil
+	}
+	if err != syscall.ENOSYS {
+		return nil, &os.PathError{Op: "openat2", Path: filepath.Join(rootfs, path), Err: err}
+	}
+
+	return walkInRoot(rootFd, rootfs, path, false, false)
+}
+
+// CreateInRoot creates the path inside the rootfs as a directory or an empty file along
+// with any missing parent directories, resolving symlinks inside the rootfs
+func CreateInRoot(rootfs, path string, isDir bool) error {
+	rootFd, err := syscall.Open(rootfs, O_PATH|syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return &os.PathError{Op: "open", Path: rootfs, Err: err}
+	}
+	defer syscall.Close(rootFd)
+
+	f, err := walkInRoot(rootFd, rootfs, path, true, isDir)
+	if err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// walkInRoot resolves the path one component at a time with O_PATH|O_NOFOLLOW opens
+// relative to the previous component, expanding symlinks itself so that neither a
+// symlink nor .. can leave the root.  If create is set missing components are created.
+func walkInRoot(rootFd int, rootfs, path string, create, isDir bool) (*os.File, error) {
+	root, err := syscall.Dup(rootFd)
+	if err != nil {
+		return nil, err
+	}
+
+	// the fds of the directories from the root to the current component
+	dirs := []int{root}
+	defer func() {
+		for _, fd := range dirs {
+			syscall.Close(fd)
+		}
+	}()
+
+	var (
+		links     int
+		remaining = splitPath(path)
+	)
+
+	for len(remaining) > 0 {
+		name := remaining[0]
+		remaining = remaining[1:]
+
+		if name == ".." {
+			if len(dirs) > 1 {
+				syscall.Close(dirs[len(dirs)-1])
+				dirs = dirs[:len(dirs)-1]
+			}
+			continue
+		}
+
+		var (
+			current = dirs[len(dirs)-1]
+			last    = len(remaining) == 0
+		)
+
+		fd, err := syscall.Openat(current, name, O_PATH|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+		if err == syscall.ENOENT && create {
+			if err := createAt(current, name, isDir || !last); err != nil {
+				return nil, &os.PathError{Op: "create", Path: filepath.Join(rootfs, path), Err: err}
+			}
+			fd, err = syscall.Openat(current, name, O_PATH|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0)
+		}
+		if err != nil {
+			return nil, &os.PathError{Op: "openat", Path: filepath.Join(rootfs, path), Err: err}
+		}
+
+		var stat syscall.Stat_t
+		if err := syscall.Fstat(fd, &stat); err != nil {
+			syscall.Close(fd)
+			return nil, err
+		}
+
+		if stat.Mode&syscall.S_IFMT != syscall.S_IFLNK {
+			dirs = append(dirs, fd)
+			continue
+		}
+
+		target, err := Readlinkat(fd, "")
+		syscall.Close(fd)
+		if err != nil {
+			return nil, err
+		}
+
+		if links++; links > maxSymlinkDepth {
+			return nil, &os.PathError{Op: "openat", Path: filepath.Join(rootfs, path), Err: syscall.ELOOP}
+		}
+
+		// absolute symlinks are resolved from the root of the container
+		if filepath.IsAbs(target) {
+			for _, fd := range dirs[1:] {
+				syscall.Close(fd)
+			}
+			dirs = dirs[:1]
+		}
+		remaining = append(splitPath(target), remaining...)
+	}
+
+	fd := dirs[len(dirs)-1]
+	if len(dirs) == 1 {
+		if fd, err = syscall.Dup(fd); err != nil {
+			return nil, err
+		}
+	} else {
+		dirs = dirs[:len(dirs)-1]
+	}
+
+	return os.NewFile(uintptr(fd), filepath.Join(rootfs, path)), nil
+}
+
+// createAt creates a directory or an empty file named name in the directory dirfd
+func createAt(dirfd int, name string, isDir bool) error {
+	if isDir {
+		if err := syscall.Mkdirat(dirfd, name, 0755); err != nil && err != syscall.EEXIST {
+			return err
+		}
+		return nil
+	}
+
+	fd, err := syscall.Openat(dirfd, name, syscall.O_CREAT|syscall.O_EXCL|syscall.O_WRONLY|syscall.O_NOFOLLOW|syscall.O_CLOEXEC, 0755)
+	if err != nil {
+		if err == syscall.EEXIST {
+			return nil
+		}
+		return err
+	}
+	return syscall.Close(fd)
+}
+
+// splitPath splits the path into its components, dropping empty and . components
+func splitPath(path string) []string {
+	var parts []string
+	for _, p := range strings.Split(path, "/") {
+		if p != "" && p != "." {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
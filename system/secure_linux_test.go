@@ -0,0 +1,89 @@
+// +build linux
+
+package system
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// newEscapeRootfs creates a rootfs with symlinks that try to reach a directory outside of it
+func newEscapeRootfs(t *testing.T) (dir, rootfs, outside string) {
+	dir, err := ioutil.TempDir("", "libcontainer-secure-")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rootfs = filepath.Join(dir, "rootfs")
+	outside = filepath.Join(dir, "outside")
+
+	for _, p := range []string{filepath.Join(rootfs, "inside"), outside} {
+		if err := os.MkdirAll(p, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	links := map[string]string{
+		"absolute": outside,
+		"relative": "../../../../../../../.." + outside,
+		"dotdot":   "inside/../../outside",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(rootfs, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir, rootfs, outside
+}
+
+func checkInRoot(t *testing.T, rootfs string, f *os.File) {
+	path, err := os.Readlink("/proc/self/fd/" + strconv.Itoa(int(f.Fd())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(path, rootfs+"/") {
+		t.Fatalf("%s resolved to %s outside of the rootfs", f.Name(), path)
+	}
+}
+
+func TestOpenInRootKeepsSymlinksInRoot(t *testing.T) {
+	dir, rootfs, outside := newEscapeRootfs(t)
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"absolute", "relative", "dotdot"} {
+		target := filepath.Join(name, "target")
+
+		if err := CreateInRoot(rootfs, target, true); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := os.Stat(filepath.Join(outside, "target")); err == nil {
+			t.Fatalf("%s created a directory outside of the rootfs", name)
+		}
+
+		f, err := OpenInRoot(rootfs, target)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkInRoot(t, rootfs, f)
+		f.Close()
+
+		// exercise the fallback used by kernels without openat2 as well
+		rootFd, err := syscall.Open(rootfs, syscall.O_DIRECTORY|syscall.O_CLOEXEC, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, err = walkInRoot(rootFd, rootfs, target, false, false)
+		syscall.Close(rootFd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkInRoot(t, rootfs, f)
+		f.Close()
+	}
+}
@@ -1,4 +1,5 @@
 // +build linux,arm
+
 package system
 
 import (